@@ -0,0 +1,209 @@
+package similardiff
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BaselineEntry identifies a difference that was accepted; the hash covers
+// the content of the pair and the line numbers are its approximate location.
+type BaselineEntry struct {
+	Hash      string
+	Group     rune
+	LeftLine  int
+	RightLine int
+	File      string
+}
+
+// Baseline is a snapshot of accepted differences. Pairs that match an entry
+// are suppressed from later comparisons, so only new differences remain.
+type Baseline struct {
+	Entries []BaselineEntry
+}
+
+// NewBaseline records the surviving pairs of every result.
+func NewBaseline(results []Result) Baseline {
+	var b Baseline
+
+	for _, r := range results {
+		for _, pair := range r.Pairs {
+			b.Entries = append(b.Entries, BaselineEntry{
+				Hash:      hashPair(pair),
+				Group:     pair.Group,
+				LeftLine:  pair.LeftLine,
+				RightLine: pair.RightLine,
+				File:      r.FileA,
+			})
+		}
+	}
+
+	return b
+}
+
+// ReadBaseline loads a baseline written by Baseline.Write.
+func ReadBaseline(filename string) (Baseline, error) {
+	var b Baseline
+
+	file, err := os.Open(filename)
+
+	if err != nil {
+		return b, &ConfigError{Name: filename, Err: err}
+	}
+
+	defer file.Close()
+
+	var number int
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		number++
+		line := scanner.Text()
+
+		/* skip comments */
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		entry, err := parseBaselineEntry(line)
+
+		if err != nil {
+			return b, &ConfigError{Name: filename, Line: number, Err: err}
+		}
+
+		b.Entries = append(b.Entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return b, &ConfigError{Name: filename, Err: err}
+	}
+
+	return b, nil
+}
+
+// parseBaselineEntry parses "hash<TAB>group<TAB>left<TAB>right<TAB>file".
+func parseBaselineEntry(line string) (BaselineEntry, error) {
+	var entry BaselineEntry
+
+	parts := strings.SplitN(line, "\t", 5)
+
+	if len(parts) != 5 || len(parts[1]) != 1 {
+		return entry, fmt.Errorf("malformed baseline entry: %q", line)
+	}
+
+	left, err := strconv.Atoi(parts[2])
+
+	if err != nil {
+		return entry, err
+	}
+
+	right, err := strconv.Atoi(parts[3])
+
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Hash = parts[0]
+	entry.Group = rune(parts[1][0])
+	entry.LeftLine = left
+	entry.RightLine = right
+	entry.File = parts[4]
+
+	return entry, nil
+}
+
+// Write saves the baseline in a line-oriented format that is easy to review
+// in version control.
+func (b Baseline) Write(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "# similardiff baseline: hash, group, line in file A, line in file B, file A"); err != nil {
+		return err
+	}
+
+	for _, e := range b.Entries {
+		_, err := fmt.Fprintf(w, "%s\t%c\t%d\t%d\t%s\n", e.Hash, e.Group, e.LeftLine, e.RightLine, e.File)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Filter moves the pairs of the result that match an entry of the baseline
+// into Suppressed. A pair matches an entry of the same file with the same
+// hash; when the same content appears more than once, the entry with the
+// nearest location is used, and every entry suppresses one pair at most.
+// The discarded pairs keep their place among the remaining ones.
+func (b Baseline) Filter(r Result) Result {
+	used := make([]bool, len(b.Entries))
+	pairs := make([]SimilarDiffPair, 0, len(r.Pairs))
+
+	/* position of every pair, and of the end, among the remaining pairs */
+	positions := make([]int, len(r.Pairs)+1)
+
+	for n, pair := range r.Pairs {
+		positions[n] = len(pairs)
+
+		hash := hashPair(pair)
+		best := -1
+		bestDistance := 0
+
+		for i, e := range b.Entries {
+			if used[i] || e.Hash != hash || e.File != r.FileA {
+				continue
+			}
+
+			distance := abs(e.LeftLine-pair.LeftLine) + abs(e.RightLine-pair.RightLine)
+
+			if best == -1 || distance < bestDistance {
+				best = i
+				bestDistance = distance
+			}
+		}
+
+		if best == -1 {
+			pairs = append(pairs, pair)
+			continue
+		}
+
+		used[best] = true
+		r.Suppressed = append(r.Suppressed, pair)
+	}
+
+	positions[len(r.Pairs)] = len(pairs)
+
+	discarded := make([]SimilarDiffDiscard, len(r.Discarded))
+
+	for n, d := range r.Discarded {
+		d.Position = positions[d.Position]
+		discarded[n] = d
+	}
+
+	r.Pairs = pairs
+	r.Discarded = discarded
+
+	return r
+}
+
+// hashPair returns a short hash of the content of the pair, including the
+// path of the differences in structured documents.
+func hashPair(pair SimilarDiffPair) string {
+	sum := sha256.Sum256([]byte(string(pair.Group) + "\x00" + pair.Left + "\x00" + pair.Right + "\x00" + pair.Path))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,109 @@
+package similardiff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	accepted := Result{
+		FileA: "a.txt",
+		Pairs: []SimilarDiffPair{
+			{Group: Changed, Left: "foo", Right: "bar", LeftLine: 3, RightLine: 3},
+			{Group: Deleted, Left: "}", LeftLine: 10},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	if err := NewBaseline([]Result{accepted}).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "baseline.txt")
+
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ReadBaseline(filename)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* lines moved and one more identical deletion appeared */
+	later := Result{
+		FileA: "a.txt",
+		Pairs: []SimilarDiffPair{
+			{Group: Changed, Left: "foo", Right: "bar", LeftLine: 5, RightLine: 5},
+			{Group: Deleted, Left: "}", LeftLine: 12},
+			{Group: Deleted, Left: "}", LeftLine: 40},
+			{Group: Added, Right: "new", RightLine: 41},
+		},
+	}
+
+	filtered := b.Filter(later)
+
+	expected := []SimilarDiffPair{
+		{Group: Deleted, Left: "}", LeftLine: 40},
+		{Group: Added, Right: "new", RightLine: 41},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: filtered.Pairs}, 2, expected)
+
+	if len(filtered.Suppressed) != 2 {
+		t.Fatalf("expecting two suppressed pairs; got %#v", filtered.Suppressed)
+	}
+
+	/* entries only apply to the file they were recorded for */
+	later.FileA = "other.txt"
+
+	if other := b.Filter(later); len(other.Pairs) != 4 {
+		t.Fatalf("unexpected pairs: %#v", other.Pairs)
+	}
+}
+
+func TestBaselineOrdered(t *testing.T) {
+	accepted := Result{
+		FileA: "a.txt",
+		Pairs: []SimilarDiffPair{
+			{Group: Changed, Left: "foo", Right: "bar", LeftLine: 1, RightLine: 1},
+		},
+	}
+
+	b := NewBaseline([]Result{accepted})
+
+	later := Result{
+		FileA: "a.txt",
+		Pairs: []SimilarDiffPair{
+			{Group: Changed, Left: "foo", Right: "bar", LeftLine: 1, RightLine: 1},
+			{Group: Changed, Left: "lorem", Right: "ipsum", LeftLine: 3, RightLine: 3},
+		},
+		Discarded: []SimilarDiffDiscard{
+			{Pair: SimilarDiffPair{Group: Changed, Left: "import a", Right: "include a", LeftLine: 2, RightLine: 2}, Position: 1},
+			{Pair: SimilarDiffPair{Group: Changed, Left: "import b", Right: "include b", LeftLine: 4, RightLine: 4}, Position: 2},
+		},
+	}
+
+	ordered := b.Filter(later).Ordered()
+
+	lines := []int{2, 3, 4}
+
+	if len(ordered) != len(lines) {
+		t.Fatalf("unexpected ordered pairs: %#v", ordered)
+	}
+
+	for i, item := range ordered {
+		if item.Pair.LeftLine != lines[i] || item.Discarded != (i != 1) {
+			t.Fatalf("unexpected pair %d: %#v", i, item)
+		}
+	}
+
+	/* the original result is not modified */
+	if later.Discarded[1].Position != 2 {
+		t.Fatalf("unexpected position: %d", later.Discarded[1].Position)
+	}
+}
@@ -0,0 +1,226 @@
+package similardiff
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// FilePair is a pair of files that are compared against each other.
+type FilePair struct {
+	FileA string
+	FileB string
+}
+
+// Comparison is the outcome of comparing one pair of files in a batch.
+type Comparison struct {
+	Result
+	Err error
+}
+
+// Failure is a file that could not be compared, either because the
+// comparison failed or because the file only exists in one of the
+// directories, in which case Err is a MissingFileError.
+type Failure struct {
+	FileA string
+	FileB string
+	Err   error
+}
+
+// DirectoryPairs lists the files found in two directories. Pairs contains the
+// files that exist in both, OnlyA and OnlyB the relative paths of the files
+// that exist in only one of them.
+type DirectoryPairs struct {
+	Pairs []FilePair
+	OnlyA []string
+	OnlyB []string
+}
+
+// CompareBatch compares every pair of files using a pool of goroutines. The
+// options, including the similarity rules, are shared read-only by all the
+// workers. The comparisons are returned in the same order as the pairs, no
+// matter the order in which they finish. A non-positive number of workers
+// uses one goroutine per CPU.
+func CompareBatch(pairs []FilePair, opts Options, workers int) []Comparison {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	if workers > len(pairs) {
+		workers = len(pairs)
+	}
+
+	var wg sync.WaitGroup
+
+	jobs := make(chan int)
+	comparisons := make([]Comparison, len(pairs))
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			/* every worker writes into its own slot */
+			for idx := range jobs {
+				result, err := CompareFiles(pairs[idx].FileA, pairs[idx].FileB, opts)
+
+				/* failed comparisons are still named after their files */
+				result.FileA = pairs[idx].FileA
+				result.FileB = pairs[idx].FileB
+
+				comparisons[idx] = Comparison{Result: result, Err: err}
+			}
+		}()
+	}
+
+	for idx := range pairs {
+		jobs <- idx
+	}
+
+	close(jobs)
+
+	wg.Wait()
+
+	return comparisons
+}
+
+// ExitStatus aggregates the comparisons following the convention of the
+// diff tool; 0 means there are no differences, 1 means that at least one
+// comparison has differences, and 2 means that at least one of them failed.
+func ExitStatus(comparisons []Comparison) int {
+	status := 0
+
+	for _, c := range comparisons {
+		if c.Err != nil {
+			return 2
+		}
+
+		if len(c.Pairs) > 0 {
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// Failures returns the comparisons that failed, followed by the files that
+// exist in only one of the directories.
+func (d DirectoryPairs) Failures(dirA string, dirB string, comparisons []Comparison) []Failure {
+	var out []Failure
+
+	for _, c := range comparisons {
+		if c.Err != nil {
+			out = append(out, Failure{FileA: c.FileA, FileB: c.FileB, Err: c.Err})
+		}
+	}
+
+	for _, name := range d.OnlyA {
+		fileB := filepath.Join(dirB, name)
+		out = append(out, Failure{FileA: filepath.Join(dirA, name), FileB: fileB, Err: &MissingFileError{Name: fileB}})
+	}
+
+	for _, name := range d.OnlyB {
+		fileA := filepath.Join(dirA, name)
+		out = append(out, Failure{FileA: fileA, FileB: filepath.Join(dirB, name), Err: &MissingFileError{Name: fileA}})
+	}
+
+	return out
+}
+
+// existing returns the file of the failure that exists, which is file A
+// unless file A is the missing one.
+func (f Failure) existing() string {
+	if missing, ok := f.Err.(*MissingFileError); ok && missing.Name == f.FileA {
+		return f.FileB
+	}
+
+	return f.FileA
+}
+
+// kind returns "missing" if one of the files does not exist, or "error".
+func (f Failure) kind() string {
+	if _, ok := f.Err.(*MissingFileError); ok {
+		return "missing"
+	}
+
+	return "error"
+}
+
+// PairDirectories walks both directories and pairs the regular files that
+// share the same relative path. The result is sorted by path.
+func PairDirectories(dirA string, dirB string) (DirectoryPairs, error) {
+	var out DirectoryPairs
+
+	filesA, err := listFiles(dirA)
+
+	if err != nil {
+		return out, err
+	}
+
+	filesB, err := listFiles(dirB)
+
+	if err != nil {
+		return out, err
+	}
+
+	for name := range filesA {
+		if _, ok := filesB[name]; ok {
+			out.Pairs = append(out.Pairs, FilePair{
+				FileA: filepath.Join(dirA, name),
+				FileB: filepath.Join(dirB, name),
+			})
+			continue
+		}
+
+		out.OnlyA = append(out.OnlyA, name)
+	}
+
+	for name := range filesB {
+		if _, ok := filesA[name]; !ok {
+			out.OnlyB = append(out.OnlyB, name)
+		}
+	}
+
+	sort.Slice(out.Pairs, func(i, j int) bool {
+		return out.Pairs[i].FileA < out.Pairs[j].FileA
+	})
+	sort.Strings(out.OnlyA)
+	sort.Strings(out.OnlyB)
+
+	return out, nil
+}
+
+// listFiles returns the relative paths of the regular files in a directory.
+func listFiles(root string) (map[string]bool, error) {
+	files := map[string]bool{}
+
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, &MissingFileError{Name: root}
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		name, err := filepath.Rel(root, path)
+
+		if err != nil {
+			return err
+		}
+
+		files[name] = true
+
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,120 @@
+package similardiff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPairDirectories(t *testing.T) {
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+
+	writeFiles(t, dirA, map[string]string{"a.txt": "A\n", "sub/c.txt": "C\n", "only-a.txt": "A\n"})
+	writeFiles(t, dirB, map[string]string{"a.txt": "A\n", "sub/c.txt": "C\n", "only-b.txt": "B\n"})
+
+	dirs, err := PairDirectories(dirA, dirB)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dirs.Pairs) != 2 {
+		t.Fatalf("expecting two pairs; got %#v", dirs.Pairs)
+	}
+
+	if dirs.Pairs[1].FileB != filepath.Join(dirB, "sub/c.txt") {
+		t.Fatalf("unexpected pair: %#v", dirs.Pairs[1])
+	}
+
+	if len(dirs.OnlyA) != 1 || dirs.OnlyA[0] != "only-a.txt" {
+		t.Fatalf("unexpected orphans in A: %#v", dirs.OnlyA)
+	}
+
+	if len(dirs.OnlyB) != 1 || dirs.OnlyB[0] != "only-b.txt" {
+		t.Fatalf("unexpected orphans in B: %#v", dirs.OnlyB)
+	}
+}
+
+func TestCompareBatch(t *testing.T) {
+	dir := t.TempDir()
+
+	var pairs []FilePair
+
+	for i := 0; i < 20; i++ {
+		fileA := filepath.Join(dir, fmt.Sprintf("%d.a", i))
+		fileB := filepath.Join(dir, fmt.Sprintf("%d.b", i))
+
+		writeFiles(t, dir, map[string]string{
+			filepath.Base(fileA): fmt.Sprintf("import %d\n", i),
+			filepath.Base(fileB): fmt.Sprintf("include %d\n", i%3),
+		})
+
+		pairs = append(pairs, FilePair{FileA: fileA, FileB: fileB})
+	}
+
+	opts := Options{Changes: []SimilarDiffChange{{Old: "import", New: "include"}}}
+
+	comparisons := CompareBatch(pairs, opts, 4)
+
+	for i, c := range comparisons {
+		if c.Err != nil {
+			t.Fatal(c.Err)
+		}
+
+		if c.FileA != pairs[i].FileA {
+			t.Fatalf("comparison %d is out of order: %s", i, c.FileA)
+		}
+
+		/* only files with the same number are similar */
+		if similar := i == i%3; similar != (len(c.Pairs) == 0) {
+			t.Fatalf("unexpected pairs in comparison %d: %#v", i, c.Pairs)
+		}
+	}
+
+	if status := ExitStatus(comparisons); status != 1 {
+		t.Fatalf("unexpected exit status: %d", status)
+	}
+}
+
+func TestDirectoryFailures(t *testing.T) {
+	dirs := DirectoryPairs{
+		Pairs: []FilePair{{FileA: "a/x.txt", FileB: "b/x.txt"}, {FileA: "a/y.txt", FileB: "b/y.txt"}},
+		OnlyA: []string{"only-a.txt"},
+		OnlyB: []string{"only-b.txt"},
+	}
+
+	comparisons := []Comparison{
+		{Result: Result{FileA: "a/x.txt", FileB: "b/x.txt"}},
+		{Result: Result{FileA: "a/y.txt", FileB: "b/y.txt"}, Err: &DiffError{Tool: "diff", Code: 2}},
+	}
+
+	failures := dirs.Failures("a", "b", comparisons)
+
+	if len(failures) != 3 || failures[0].FileA != "a/y.txt" || failures[0].kind() != "error" {
+		t.Fatalf("unexpected failures: %#v", failures)
+	}
+
+	if f := failures[1]; f.kind() != "missing" || f.existing() != filepath.Join("a", "only-a.txt") {
+		t.Fatalf("unexpected failure: %#v", f)
+	}
+
+	if f := failures[2]; f.kind() != "missing" || f.existing() != filepath.Join("b", "only-b.txt") {
+		t.Fatalf("unexpected failure: %#v", f)
+	}
+}
@@ -0,0 +1,217 @@
+package similardiff
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// binaryProbe is the number of bytes inspected to detect binary files.
+const binaryProbe = 8000
+
+// hexdumpWidth is the number of bytes in every row of the byte-level view.
+const hexdumpWidth = 16
+
+// IsBinary reports whether the file looks like a binary file, that is, if
+// there is a NUL byte among its first bytes, the same heuristic used by git.
+// Text files encoded in UTF-16 with a byte order mark are not binary.
+func IsBinary(name string) (bool, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer file.Close()
+
+	buf := make([]byte, binaryProbe)
+	n, err := io.ReadFull(file, buf)
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+
+	if charset := detectCharset(buf[:n]); charset == UTF16LE || charset == UTF16BE {
+		return false, nil
+	}
+
+	return bytes.IndexByte(buf[:n], 0) >= 0, nil
+}
+
+// CompareBinary compares two binary files.
+//
+// By default, the result has one pair, located at the offset of the first
+// different byte, that describes the size of both files. If the byte-level
+// comparison is enabled, the files are compared in rows of 16 bytes at the
+// same offsets and every different row becomes a pair in hexdump format,
+// located by its offset range. The byte patterns in ByteChanges are replaced
+// in file A before comparing the rows; patterns of the same length keep the
+// offsets aligned.
+func CompareBinary(fileA string, fileB string, opts Options) (Result, error) {
+	result := Result{FileA: fileA, FileB: fileB}
+
+	if err := checkFiles(fileA, fileB); err != nil {
+		return result, err
+	}
+
+	a, err := os.ReadFile(fileA)
+
+	if err != nil {
+		return result, &ParseError{Name: fileA, Err: err}
+	}
+
+	b, err := os.ReadFile(fileB)
+
+	if err != nil {
+		return result, &ParseError{Name: fileB, Err: err}
+	}
+
+	if bytes.Equal(a, b) {
+		return result, nil
+	}
+
+	if !opts.ByteLevel {
+		result.Notes = append(result.Notes, "binary files differ; enable the byte-level comparison to see the different bytes")
+		result.Pairs = append(result.Pairs, SimilarDiffPair{
+			Group: Changed,
+			Left:  fmt.Sprintf("binary file, %d bytes", len(a)),
+			Right: fmt.Sprintf("binary file, %d bytes", len(b)),
+			Path:  fmt.Sprintf("0x%08x", firstDifference(a, b)),
+		})
+		return result, nil
+	}
+
+	normalized := a
+
+	var rules []SimilarDiffChange
+
+	for _, change := range opts.ByteChanges {
+		if bytes.Contains(normalized, []byte(change.Old)) {
+			normalized = bytes.ReplaceAll(normalized, []byte(change.Old), []byte(change.New))
+			rules = append(rules, change)
+		}
+	}
+
+	for offset := 0; offset < len(a) || offset < len(b); offset += hexdumpWidth {
+		rowA := byteRow(a, offset)
+		rowB := byteRow(b, offset)
+
+		if bytes.Equal(rowA, rowB) {
+			continue
+		}
+
+		pair := SimilarDiffPair{Group: Changed, Path: byteRange(offset, rowA, rowB)}
+
+		switch {
+		case rowA == nil:
+			pair.Group = Added
+		case rowB == nil:
+			pair.Group = Deleted
+		}
+
+		if rowA != nil {
+			pair.Left = hexdump(rowA)
+		}
+
+		if rowB != nil {
+			pair.Right = hexdump(rowB)
+		}
+
+		/* bytes are similar */
+		if pair.Group == Changed && bytes.Equal(byteRow(normalized, offset), rowB) {
+			result.Discarded = append(result.Discarded, SimilarDiffDiscard{
+				Pair:     pair,
+				Rules:    rules,
+				Position: len(result.Pairs),
+			})
+			continue
+		}
+
+		result.Pairs = append(result.Pairs, pair)
+	}
+
+	return result, nil
+}
+
+// ParseBytePattern decodes a pattern written as hexadecimal bytes, which can
+// be separated by spaces, for example "de ad be ef".
+func ParseBytePattern(pattern string) (string, error) {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(pattern), ""))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// byteRow returns the row of bytes at the offset, or nil past the end.
+func byteRow(data []byte, offset int) []byte {
+	if offset >= len(data) {
+		return nil
+	}
+
+	end := offset + hexdumpWidth
+
+	if end > len(data) {
+		end = len(data)
+	}
+
+	return data[offset:end]
+}
+
+// byteRange returns the offsets covered by the longest of both rows.
+func byteRange(offset int, a []byte, b []byte) string {
+	size := len(a)
+
+	if len(b) > size {
+		size = len(b)
+	}
+
+	return fmt.Sprintf("0x%08x-0x%08x", offset, offset+size-1)
+}
+
+// hexdump formats the bytes like "hexdump -C" does, without the offset.
+func hexdump(row []byte) string {
+	var out strings.Builder
+
+	for i := 0; i < hexdumpWidth; i++ {
+		if i < len(row) {
+			fmt.Fprintf(&out, "%02x ", row[i])
+		} else {
+			out.WriteString("   ")
+		}
+
+		if i == hexdumpWidth/2-1 {
+			out.WriteString(" ")
+		}
+	}
+
+	out.WriteString(" |")
+
+	for _, c := range row {
+		if c < 32 || c > 126 {
+			c = '.'
+		}
+
+		out.WriteByte(c)
+	}
+
+	out.WriteString("|")
+
+	return out.String()
+}
+
+// firstDifference returns the offset of the first different byte.
+func firstDifference(a []byte, b []byte) int {
+	var i int
+
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	return i
+}
@@ -0,0 +1,70 @@
+package similardiff
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareBinary(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.bin": "abc\x00def\xde\xad\xbe\xefxyz0123456789ABCDEF",
+		"b.bin": "abc\x00def\xca\xfe\xba\xbexyz0123456789abcdef!",
+	})
+
+	fileA := filepath.Join(dir, "a.bin")
+	fileB := filepath.Join(dir, "b.bin")
+
+	if binary, err := IsBinary(fileA); err != nil || !binary {
+		t.Fatalf("expecting a binary file; got %v, %v", binary, err)
+	}
+
+	result, err := CompareFiles(fileA, fileB, Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "binary file, 30 bytes", Right: "binary file, 31 bytes", Path: "0x00000007"},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	pattern, err := ParseBytePattern("de ad be ef")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replacement, err := ParseBytePattern("cafebabe")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = CompareFiles(fileA, fileB, Options{
+		ByteLevel:   true,
+		ByteChanges: []SimilarDiffChange{{Old: pattern, New: replacement}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []SimilarDiffPair{
+		{
+			Group: 'c',
+			Left:  "32 33 34 35 36 37 38 39  41 42 43 44 45 46        |23456789ABCDEF|",
+			Right: "32 33 34 35 36 37 38 39  61 62 63 64 65 66 21     |23456789abcdef!|",
+			Path:  "0x00000010-0x0000001e",
+		},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	if len(result.Discarded) != 1 || result.Discarded[0].Pair.Path != "0x00000000-0x0000000f" {
+		t.Fatalf("expecting the first row to be discarded; got %#v", result.Discarded)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cixtor/similardiff"
+)
+
+// runLSP implements "similardiff lsp", a language server that talks to the
+// editor through the standard input and output.
+func runLSP(args []string) {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	config := fs.String("config", "similardiff.ini", "Name of the configuration file in the root of the workspace")
+
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  similardiff lsp")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Options:")
+		fs.PrintDefaults()
+	}
+
+	if len(parseArgs(fs, args)) != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	s := similardiff.NewLanguageServer()
+	s.ConfigName = *config
+
+	if err := s.Serve(os.Stdin, os.Stdout); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/cixtor/similardiff"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Println("Similar Diff")
+		fmt.Println("https://cixtor.com/")
+		fmt.Println("https://github.com/cixtor/similardiff")
+		fmt.Println("https://en.wikipedia.org/wiki/Edit_distance")
+		fmt.Println("https://en.wikipedia.org/wiki/File_comparison")
+		fmt.Println("https://en.wikipedia.org/wiki/Levenshtein_distance")
+		fmt.Println()
+		fmt.Println("Usage:")
+		fmt.Println("  similardiff [OPTIONS] [FILE_A] [FILE_B]")
+		fmt.Println("  similardiff [OPTIONS] [DIR_A] [DIR_B]")
+		fmt.Println("  similardiff [OPTIONS] [BASE] [FILE_A] [FILE_B]")
+		fmt.Println("  similardiff merge [FILE_A] [FILE_B] -o [OUTPUT]")
+		fmt.Println("  similardiff suggest [FILE_A] [FILE_B] -o [OUTPUT]")
+		fmt.Println("  similardiff review [FILE_A] [FILE_B]")
+		fmt.Println("  similardiff serve -addr [ADDRESS]")
+		fmt.Println("  similardiff lsp")
+		fmt.Println("  similardiff run [MANIFEST]")
+		fmt.Println()
+		fmt.Println("Options:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("Settings:")
+		fmt.Println("  export SIMILARDIFF_COLOR=true")
+		fmt.Println("  echo \"#file_a=file_b\" 1>> similardiff.ini")
+		fmt.Println("  echo \"import=include\" 1>> similardiff.ini")
+		fmt.Println("  echo \"package=module\" 1>> similardiff.ini")
+		fmt.Println("  similardiff file_a.txt file_b.txt")
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "merge":
+			runMerge(os.Args[2:])
+			return
+		case "suggest":
+			runSuggest(os.Args[2:])
+			return
+		case "review":
+			runReview(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "lsp":
+			runLSP(os.Args[2:])
+			return
+		case "run":
+			runManifest(os.Args[2:])
+			return
+		}
+	}
+
+	stream := flag.Bool("stream", false, "Process the differences hunk by hunk to bound memory usage")
+	watch := flag.Bool("watch", false, "Compare again every time the files or the configuration change")
+	structural := flag.Bool("structural", true, "Compare JSON and YAML files as trees instead of lines")
+	tabular := flag.Bool("tabular", true, "Compare CSV and TSV files row by row instead of line by line")
+	keys := flag.String("key", "", "Comma-separated key columns that match the rows of CSV and TSV files")
+	ignore := flag.String("ignore", "", "Comma-separated columns of CSV and TSV files that are not compared")
+	moves := flag.Bool("moves", true, "Report blocks of lines moved within the file instead of deleted and added lines")
+	byteLevel := flag.Bool("bytes", false, "Compare binary files byte by byte in hexdump format")
+	context := flag.Int("C", 0, "Print this number of unchanged lines around the differences")
+	unified := flag.Int("U", 0, "Same as -C")
+	stat := flag.Bool("stat", false, "Print the number of differences per file before and after the rules instead of the differences")
+	format := flag.String("format", "text", "Output format: text, html, junit or sarif")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of files compared concurrently when comparing directories")
+	baseline := flag.String("baseline", "", "Suppress the differences accepted in this baseline file")
+	writeBaseline := flag.String("write-baseline", "", "Accept the current differences and record them in this baseline file")
+
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flag.NArg() < 2 {
+		fmt.Println("missing file operand after", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config, err := similardiff.LoadConfig("similardiff.ini")
+
+	if err != nil {
+		fail(err)
+	}
+
+	opts := similardiff.Options{
+		Changes:    config.Changes,
+		Structural: *structural,
+		Tabular:    *tabular,
+		Keys:       splitList(*keys),
+		Ignore:     splitList(*ignore),
+		Columns:    config.Columns,
+		Tolerances: config.Tolerances,
+
+		ByteLevel:   *byteLevel,
+		ByteChanges: config.Bytes,
+
+		Moves: *moves,
+	}
+
+	p := similardiff.NewPrinter(os.Stdout)
+
+	p.SetColorize(os.Getenv("SIMILARDIFF_COLOR"))
+	p.SetContext(max(*context, *unified))
+
+	if !validFormat(*format) {
+		fmt.Println("unsupported format", *format)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *stat && *format != "text" {
+		fmt.Println("-stat cannot be combined with", *format, "output")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *stream && (*baseline != "" || *writeBaseline != "" || *stat || p.Context > 0) {
+		fmt.Println("-stream cannot be combined with baselines, -stat or context lines")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	o := &output{
+		printer:       p,
+		format:        *format,
+		stat:          *stat,
+		baseline:      *baseline,
+		writeBaseline: *writeBaseline,
+	}
+
+	if flag.NArg() == 3 {
+		result, err := similardiff.CompareThreeWay(flag.Arg(0), flag.Arg(1), flag.Arg(2), opts)
+
+		if err != nil {
+			fail(err)
+		}
+
+		p.PrintThreeWay(result)
+		return
+	}
+
+	if isDir(flag.Arg(0)) && isDir(flag.Arg(1)) {
+		os.Exit(compareDirectories(o, flag.Arg(0), flag.Arg(1), opts, *workers))
+	}
+
+	if *watch {
+		watchChanges(p, flag.Arg(0), flag.Arg(1), "similardiff.ini", opts)
+		return
+	}
+
+	/* structured documents and tables are parsed as a whole */
+	if *stream && *format == "text" && !(*structural && similardiff.IsStructured(flag.Arg(0))) && !(*tabular && similardiff.IsTabular(flag.Arg(0))) {
+		streamChanges(p, flag.Arg(0), flag.Arg(1), opts)
+		return
+	}
+
+	result, err := similardiff.CompareFiles(flag.Arg(0), flag.Arg(1), opts)
+
+	if err != nil {
+		fail(err)
+	}
+
+	o.report([]similardiff.Result{result}, nil)
+}
+
+// output holds the settings that decide how the results are reported.
+type output struct {
+	printer       *similardiff.Printer
+	format        string
+	stat          bool
+	baseline      string
+	writeBaseline string
+}
+
+// report suppresses the differences accepted in the baseline, if any, and
+// writes the remaining ones in the requested format, along with the files
+// that could not be compared in the formats that support them. When a new
+// baseline is requested, the differences are recorded instead and the
+// program ends.
+func (o *output) report(results []similardiff.Result, failures []similardiff.Failure) []similardiff.Result {
+	if o.writeBaseline != "" {
+		o.saveBaseline(results)
+		os.Exit(0)
+	}
+
+	if o.baseline != "" {
+		b, err := similardiff.ReadBaseline(o.baseline)
+
+		if err != nil {
+			fail(err)
+		}
+
+		for i := range results {
+			results[i] = b.Filter(results[i])
+		}
+	}
+
+	if o.stat {
+		stats := make([]similardiff.Stat, len(results))
+
+		for i, result := range results {
+			stats[i] = similardiff.NewStat(result)
+		}
+
+		o.printer.PrintStats(stats, 10)
+		return results
+	}
+
+	writers := map[string]func(io.Writer, []similardiff.Result, []similardiff.Failure) error{
+		"junit": similardiff.WriteJUnit,
+		"sarif": similardiff.WriteSARIF,
+	}
+
+	if write, ok := writers[o.format]; ok {
+		if err := write(os.Stdout, results, failures); err != nil {
+			fail(err)
+		}
+		return results
+	}
+
+	if o.format == "html" {
+		if err := similardiff.WriteHTML(os.Stdout, results); err != nil {
+			fail(err)
+		}
+		return results
+	}
+
+	for _, result := range results {
+		o.printer.PrettyPrint(result)
+	}
+
+	return results
+}
+
+func (o *output) saveBaseline(results []similardiff.Result) {
+	b := similardiff.NewBaseline(results)
+
+	file, err := os.Create(o.writeBaseline)
+
+	if err != nil {
+		fail(err)
+	}
+
+	if err := b.Write(file); err != nil {
+		fail(err)
+	}
+
+	if err := file.Close(); err != nil {
+		fail(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "similardiff: %d differences recorded in %s\n", len(b.Entries), o.writeBaseline)
+}
+
+// streamChanges prints every surviving pair as soon as it is found.
+func streamChanges(p *similardiff.Printer, fileA string, fileB string, opts similardiff.Options) {
+	var header bool
+
+	/* print the header only if there are changes or notes */
+	printHeader := func() {
+		if !header {
+			p.PrintHeader(fileA, fileB)
+			header = true
+		}
+	}
+
+	note := func(text string) error {
+		printHeader()
+		p.PrintCyan("# %s", text)
+		return nil
+	}
+
+	err := similardiff.CompareStream(fileA, fileB, opts, note, func(pair similardiff.SimilarDiffPair) error {
+		printHeader()
+		p.PrintPair(pair)
+		return nil
+	})
+
+	if err != nil {
+		fail(err)
+	}
+}
+
+// compareDirectories compares the files that exist in both directories and
+// returns the aggregated exit status; files that exist in only one of the
+// directories are reported as differences.
+func compareDirectories(o *output, dirA string, dirB string, opts similardiff.Options, workers int) int {
+	dirs, err := similardiff.PairDirectories(dirA, dirB)
+
+	if err != nil {
+		fail(err)
+	}
+
+	comparisons := similardiff.CompareBatch(dirs.Pairs, opts, workers)
+	results := make([]similardiff.Result, 0, len(comparisons))
+
+	for _, c := range comparisons {
+		if c.Err != nil {
+			fmt.Fprintf(os.Stderr, "similardiff: %s\n", c.Err)
+			continue
+		}
+
+		results = append(results, c.Result)
+	}
+
+	results = o.report(results, dirs.Failures(dirA, dirB, comparisons))
+
+	if o.format == "text" {
+		for _, name := range dirs.OnlyA {
+			fmt.Printf("Only in %s: %s\n", dirA, name)
+		}
+
+		for _, name := range dirs.OnlyB {
+			fmt.Printf("Only in %s: %s\n", dirB, name)
+		}
+	}
+
+	if similardiff.ExitStatus(comparisons) == 2 {
+		return 2
+	}
+
+	for _, result := range results {
+		if len(result.Pairs) > 0 {
+			return 1
+		}
+	}
+
+	if len(dirs.OnlyA)+len(dirs.OnlyB) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// parseArgs parses the flags of a subcommand, which can appear before or
+// after the positional arguments, and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+
+	for {
+		_ = fs.Parse(args) /* ExitOnError */
+
+		if fs.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	return positional
+}
+
+func validFormat(format string) bool {
+	switch format {
+	case "text", "html", "junit", "sarif":
+		return true
+	}
+
+	return false
+}
+
+// splitList splits a comma-separated list, ignoring empty items.
+func splitList(value string) []string {
+	var list []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+func isDir(name string) bool {
+	info, err := os.Stat(name)
+
+	return err == nil && info.IsDir()
+}
+
+// fail prints a message that explains the error and terminates the program.
+func fail(err error) {
+	var missing *similardiff.MissingFileError
+	var config *similardiff.ConfigError
+	var diff *similardiff.DiffError
+	var parse *similardiff.ParseError
+
+	switch {
+	case errors.As(err, &missing):
+		fmt.Fprintf(os.Stderr, "similardiff: %s does not exist\n", missing.Name)
+	case errors.As(err, &config):
+		fmt.Fprintf(os.Stderr, "similardiff: cannot use configuration; %s\n", config)
+	case errors.As(err, &parse):
+		fmt.Fprintf(os.Stderr, "similardiff: invalid document; %s\n", parse)
+	case errors.As(err, &diff):
+		fmt.Fprintf(os.Stderr, "similardiff: cannot compare files; %s\n", diff)
+	default:
+		fmt.Fprintf(os.Stderr, "similardiff: %s\n", err)
+	}
+
+	os.Exit(2)
+}
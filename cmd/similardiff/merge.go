@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cixtor/similardiff"
+)
+
+// runMerge implements "similardiff merge FILE_A FILE_B [-o OUTPUT]".
+func runMerge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("o", "", "Write the merged file here instead of the standard output")
+
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("  similardiff merge [FILE_A] [FILE_B] -o [OUTPUT]")
+		fmt.Println()
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	files := parseArgs(fs, args)
+
+	if len(files) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	changes, err := similardiff.LoadChanges("similardiff.ini")
+
+	if err != nil {
+		fail(err)
+	}
+
+	var w io.Writer = os.Stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+
+		if err != nil {
+			fail(err)
+		}
+
+		defer file.Close()
+
+		w = file
+	}
+
+	conflicts, err := similardiff.Merge(w, files[0], files[1], similardiff.Options{Changes: changes})
+
+	if err != nil {
+		fail(err)
+	}
+
+	if conflicts > 0 {
+		fmt.Fprintf(os.Stderr, "similardiff: %d conflicts\n", conflicts)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/cixtor/similardiff"
+)
+
+// reviewer holds the state of the interactive review.
+type reviewer struct {
+	*similardiff.Review
+
+	config string
+	status string
+}
+
+// runReview implements "similardiff review FILE_A FILE_B".
+func runReview(args []string) {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("  similardiff review [FILE_A] [FILE_B]")
+		fmt.Println()
+		fmt.Println("Keys:")
+		fmt.Println("  j, k     move to the next or previous pair")
+		fmt.Println("  n, p     move to the next or previous hunk")
+		fmt.Println("  s        show or hide the discarded similarities")
+		fmt.Println("  r        append the highlighted substitution to similardiff.ini")
+		fmt.Println("  q        quit")
+	}
+
+	files := parseArgs(fs, args)
+
+	if len(files) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	r := &reviewer{config: "similardiff.ini"}
+
+	config, err := similardiff.LoadConfig(r.config)
+
+	if err != nil {
+		fail(err)
+	}
+
+	opts := similardiff.Options{Changes: config.Changes, Tolerances: config.Tolerances}
+
+	result, err := similardiff.CompareFiles(files[0], files[1], opts)
+
+	if err != nil {
+		fail(err)
+	}
+
+	r.Review = similardiff.NewReview(result, opts)
+
+	restore, err := rawTerminal()
+
+	if err != nil {
+		fail(err)
+	}
+
+	defer restore()
+
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		r.draw()
+
+		key, err := readKey(reader)
+
+		if err != nil || key == "q" {
+			break
+		}
+
+		r.handle(key)
+	}
+
+	fmt.Print("\033[H\033[2J")
+}
+
+// handle updates the state of the review after a key press.
+func (r *reviewer) handle(key string) {
+	r.status = ""
+
+	switch key {
+	case "j", "down":
+		r.Move(1)
+	case "k", "up":
+		r.Move(-1)
+	case "n":
+		r.MoveHunk(1)
+	case "p":
+		r.MoveHunk(-1)
+	case "s":
+		r.ToggleSimilar()
+	case "r":
+		r.addRule()
+	}
+}
+
+// addRule appends the substitution suggested for the highlighted pair to the
+// configuration file and filters the pairs again.
+func (r *reviewer) addRule() {
+	change, ok := r.Suggestion()
+
+	if !ok {
+		r.status = "no substitution to add"
+		return
+	}
+
+	if err := similardiff.AppendChange(r.config, change); err != nil {
+		r.status = err.Error()
+		return
+	}
+
+	r.Accept(change)
+	r.status = fmt.Sprintf("added %s=%s to %s", change.Old, change.New, r.config)
+}
+
+// draw renders the pairs around the cursor that fit in the terminal.
+func (r *reviewer) draw() {
+	var buf bytes.Buffer
+
+	rows, _ := terminalSize()
+	height := rows - 4 /* header and status lines */
+
+	if height < 2 {
+		height = 2
+	}
+
+	p := similardiff.NewPrinter(&buf)
+	p.Colorize = true
+
+	buf.WriteString("\033[H\033[2J")
+
+	p.PrintHeader(r.Result.FileA, r.Result.FileB)
+
+	start := r.Cursor - height/4
+
+	if start < 0 {
+		start = 0
+	}
+
+	for i, used := start, 0; i < len(r.Items) && used < height; i++ {
+		item := r.Items[i]
+
+		marker := "  "
+
+		if i == r.Cursor {
+			marker = "> "
+		}
+
+		if item.Discarded {
+			var rules []string
+
+			for _, rule := range item.Rules {
+				rules = append(rules, rule.String())
+			}
+
+			fmt.Fprintf(&buf, "%s\033[2m%d\t~%s  (%s)\033[0m\n", marker, item.Pair.LeftLine, item.Pair.Left, strings.Join(rules, ", "))
+			used++
+			continue
+		}
+
+		if item.Pair.LeftLine > 0 {
+			p.PrintRed("%s%d\t-%s", marker, item.Pair.LeftLine, item.Pair.Left)
+			marker = "  "
+			used++
+		}
+
+		if item.Pair.RightLine > 0 {
+			p.PrintGreen("%s%d\t+%s", marker, item.Pair.RightLine, item.Pair.Right)
+			used++
+		}
+	}
+
+	fmt.Fprintf(&buf, "\n%d differences, %d similarities", len(r.Result.Pairs), len(r.Result.Discarded))
+
+	if change, ok := r.Suggestion(); ok {
+		fmt.Fprintf(&buf, ", press r to add \033[1m%s=%s\033[0m", change.Old, change.New)
+	}
+
+	if r.status != "" {
+		fmt.Fprintf(&buf, " | %s", r.status)
+	}
+
+	os.Stdout.Write(buf.Bytes())
+}
+
+// readKey reads one key press, arrow keys are translated into names.
+func readKey(reader *bufio.Reader) (string, error) {
+	b, err := reader.ReadByte()
+
+	if err != nil {
+		return "", err
+	}
+
+	if b != 0x1b {
+		return string(b), nil
+	}
+
+	/* escape sequences: ESC [ A (up) and ESC [ B (down) */
+	seq := make([]byte, 2)
+
+	if _, err := reader.Read(seq); err != nil {
+		return "", err
+	}
+
+	switch string(seq) {
+	case "[A":
+		return "up", nil
+	case "[B":
+		return "down", nil
+	}
+
+	return "", nil
+}
+
+// rawTerminal disables line buffering and echo, and returns a function that
+// restores the previous settings of the terminal.
+func rawTerminal() (func(), error) {
+	state, err := stty("-g")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+
+	fmt.Print("\033[?25l") /* hide cursor */
+
+	return func() {
+		fmt.Print("\033[?25h") /* show cursor */
+		_, _ = stty(strings.TrimSpace(state))
+	}, nil
+}
+
+// terminalSize returns the number of rows and columns of the terminal.
+func terminalSize() (int, int) {
+	out, err := stty("size")
+
+	if err != nil {
+		return 24, 80
+	}
+
+	var rows, cols int
+
+	fields := strings.Fields(out)
+
+	if len(fields) == 2 {
+		rows, _ = strconv.Atoi(fields[0])
+		cols, _ = strconv.Atoi(fields[1])
+	}
+
+	return rows, cols
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+
+	return string(out), err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/cixtor/similardiff"
+)
+
+// runManifest implements "similardiff run MANIFEST".
+func runManifest(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format: text, html, junit or sarif")
+	stat := fs.Bool("stat", false, "Print the number of differences per file before and after the rules instead of the differences")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of files compared concurrently when comparing directories")
+
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("  similardiff run [MANIFEST]")
+		fmt.Println()
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	files := parseArgs(fs, args)
+
+	if len(files) != 1 || !validFormat(*format) || (*stat && *format != "text") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	m, err := similardiff.LoadManifest(files[0])
+
+	if err != nil {
+		fail(err)
+	}
+
+	p := similardiff.NewPrinter(os.Stdout)
+
+	p.SetColorize(os.Getenv("SIMILARDIFF_COLOR"))
+
+	opts := similardiff.Options{Structural: true, Tabular: true, Moves: true}
+	outcomes := m.Run(opts, *workers)
+
+	var results []similardiff.Result
+	var failures []similardiff.Failure
+
+	for _, o := range outcomes {
+		for _, c := range o.Comparisons {
+			if c.Err == nil {
+				results = append(results, c.Result)
+			}
+		}
+
+		failures = append(failures, o.Failures()...)
+	}
+
+	o := &output{printer: p, format: *format, stat: *stat}
+
+	o.report(results, failures)
+
+	/* the summary goes to the standard error next to other formats */
+	summary := os.Stdout
+
+	if *format != "text" {
+		summary = os.Stderr
+	}
+
+	for _, o := range outcomes {
+		for _, name := range o.OnlyA {
+			fmt.Fprintf(summary, "Only in %s: %s\n", o.Entry.A, name)
+		}
+
+		for _, name := range o.OnlyB {
+			fmt.Fprintf(summary, "Only in %s: %s\n", o.Entry.B, name)
+		}
+	}
+
+	for _, o := range outcomes {
+		switch {
+		case o.Failed() != nil:
+			fmt.Fprintf(summary, "ERROR %s: %s\n", o.Entry.Name, o.Failed())
+		case o.Passed():
+			fmt.Fprintf(summary, "PASS  %s\n", o.Entry.Name)
+		default:
+			fmt.Fprintf(summary, "FAIL  %s: expected %s, found %d differences\n", o.Entry.Name, o.Entry.Expect, o.Differences())
+		}
+	}
+
+	os.Exit(similardiff.ManifestStatus(outcomes))
+}
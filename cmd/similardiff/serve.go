@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cixtor/similardiff"
+)
+
+// runServe implements "similardiff serve [-addr ADDRESS]".
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Listen for HTTP requests on this address")
+	maxBytes := fs.Int64("max-bytes", 10<<20, "Reject requests with a larger body")
+	timeout := fs.Duration("timeout", 30*time.Second, "Abort the comparisons that take longer")
+
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("  similardiff serve -addr [ADDRESS]")
+		fmt.Println()
+		fmt.Println("Endpoint:")
+		fmt.Println("  POST /compare {\"a\": \"...\", \"b\": \"...\", \"rules\": [{\"old\": \"...\", \"new\": \"...\"}]}")
+		fmt.Println()
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if len(parseArgs(fs, args)) != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	/* rules in the configuration file apply to every request */
+	config, err := similardiff.LoadConfig("similardiff.ini")
+
+	if err != nil {
+		fail(err)
+	}
+
+	s := similardiff.NewServer()
+	s.MaxBytes = *maxBytes
+	s.Timeout = *timeout
+	s.Options = similardiff.Options{Changes: config.Changes, Tolerances: config.Tolerances}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *timeout,
+		WriteTimeout:      *timeout + 10*time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	fmt.Fprintf(os.Stderr, "similardiff: listening on %s\n", *addr)
+
+	if err := server.ListenAndServe(); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/cixtor/similardiff"
+)
+
+// runSuggest implements "similardiff suggest FILE_A FILE_B [-o OUTPUT]".
+func runSuggest(args []string) {
+	fs := flag.NewFlagSet("suggest", flag.ExitOnError)
+	output := fs.String("o", "", "Write the rules here instead of the standard output")
+
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("  similardiff suggest [FILE_A] [FILE_B] -o [OUTPUT]")
+		fmt.Println("  similardiff suggest [DIR_A] [DIR_B] -o [OUTPUT]")
+		fmt.Println()
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	files := parseArgs(fs, args)
+
+	if len(files) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	changes, err := similardiff.LoadChanges("similardiff.ini")
+
+	if err != nil {
+		fail(err)
+	}
+
+	opts := similardiff.Options{Changes: changes}
+	pairs := []similardiff.FilePair{{FileA: files[0], FileB: files[1]}}
+
+	if isDir(files[0]) && isDir(files[1]) {
+		dirs, err := similardiff.PairDirectories(files[0], files[1])
+
+		if err != nil {
+			fail(err)
+		}
+
+		pairs = dirs.Pairs
+	}
+
+	var found []similardiff.SimilarDiffPair
+
+	for _, c := range similardiff.CompareBatch(pairs, opts, runtime.NumCPU()) {
+		if c.Err != nil {
+			fail(c.Err)
+		}
+
+		found = append(found, c.Pairs...)
+	}
+
+	var w io.Writer = os.Stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+
+		if err != nil {
+			fail(err)
+		}
+
+		defer file.Close()
+
+		w = file
+	}
+
+	if err := similardiff.WriteSuggestions(w, similardiff.SuggestChanges(found, changes)); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cixtor/similardiff"
+)
+
+// watchInterval is how often the watched files are polled for changes.
+const watchInterval = 500 * time.Millisecond
+
+// watchChanges runs the comparison every time one of the compared files or
+// the configuration file changes, redraws the output, and shows which
+// differences appeared or were resolved since the previous run.
+func watchChanges(p *similardiff.Printer, fileA string, fileB string, config string, opts similardiff.Options) {
+	var last string
+	var prev *similardiff.Result
+
+	for {
+		stamp := fileStamp(fileA, fileB, config)
+
+		if stamp == last {
+			time.Sleep(watchInterval)
+			continue
+		}
+
+		last = stamp
+
+		fmt.Print("\033[H\033[2J")
+
+		result, err := watchOnce(fileA, fileB, config, opts)
+
+		if err != nil {
+			/* keep watching; the files may be in the middle of a change */
+			fmt.Fprintf(os.Stderr, "similardiff: %s\n", err)
+			continue
+		}
+
+		p.PrettyPrint(result)
+
+		fmt.Println()
+		fmt.Printf("%s: %d differences, %d similarities\n", time.Now().Format("15:04:05"), len(result.Pairs), len(result.Discarded))
+
+		if prev != nil {
+			delta := similardiff.Delta(*prev, result)
+
+			for _, pair := range delta.Appeared {
+				p.PrintRed("new\t%s", describePair(pair))
+			}
+
+			for _, pair := range delta.Resolved {
+				p.PrintGreen("resolved\t%s", describePair(pair))
+			}
+		}
+
+		prev = &result
+	}
+}
+
+// watchOnce loads the configuration again and runs the comparison.
+func watchOnce(fileA string, fileB string, config string, opts similardiff.Options) (similardiff.Result, error) {
+	c, err := similardiff.LoadConfig(config)
+
+	if err != nil {
+		return similardiff.Result{}, err
+	}
+
+	opts.Changes = c.Changes
+	opts.Columns = c.Columns
+	opts.ByteChanges = c.Bytes
+	opts.Tolerances = c.Tolerances
+
+	return similardiff.CompareFiles(fileA, fileB, opts)
+}
+
+// fileStamp summarizes the modification time and size of the files; files
+// that do not exist are part of the summary too.
+func fileStamp(names ...string) string {
+	var stamp string
+
+	for _, name := range names {
+		info, err := os.Stat(name)
+
+		if err != nil {
+			stamp += name + ":missing;"
+			continue
+		}
+
+		stamp += fmt.Sprintf("%s:%d:%d;", name, info.ModTime().UnixNano(), info.Size())
+	}
+
+	return stamp
+}
+
+func describePair(pair similardiff.SimilarDiffPair) string {
+	switch pair.Group {
+	case similardiff.Added:
+		return fmt.Sprintf("%d +%s", pair.RightLine, pair.Right)
+	case similardiff.Deleted:
+		return fmt.Sprintf("%d -%s", pair.LeftLine, pair.Left)
+	case similardiff.Moved:
+		return fmt.Sprintf("%d moved to %d, %d lines", pair.LeftLine, pair.RightLine, similardiff.MovedLines(pair))
+	}
+
+	return fmt.Sprintf("%d -%s +%s", pair.LeftLine, pair.Left, pair.Right)
+}
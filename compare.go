@@ -0,0 +1,294 @@
+package similardiff
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+// Options configures a comparison.
+type Options struct {
+	// Changes is the list of similarities used to discard differences.
+	Changes []SimilarDiffChange
+
+	// Structural compares JSON and YAML documents as trees instead of lines.
+	Structural bool
+
+	// Tabular compares CSV and TSV files row by row instead of line by line.
+	// Rows are matched by the Keys columns, the Ignore columns are skipped,
+	// and Columns holds the similarities that only apply to some columns.
+	Tabular bool
+	Keys    []string
+	Ignore  []string
+	Columns map[string][]SimilarDiffChange
+
+	// ByteLevel compares binary files byte by byte, in hexdump format, and
+	// ByteChanges holds the byte patterns that are considered similar.
+	ByteLevel   bool
+	ByteChanges []SimilarDiffChange
+
+	// Tolerances holds the numeric tolerance rules, see KindTolerance, of
+	// the files whose name or path matches the glob pattern of the key; the
+	// "*" key matches every file.
+	Tolerances map[string][]SimilarDiffChange
+
+	// Moves reports the blocks of lines that were moved within the file as
+	// one pair of the Moved group instead of deleted and added lines.
+	Moves bool
+}
+
+// Result holds the differences that survived the similarity rules, and the
+// ones that were discarded by them. Suppressed holds the differences that
+// were accepted in a baseline, and Notes the remarks about the files as a
+// whole.
+type Result struct {
+	FileA      string
+	FileB      string
+	Pairs      []SimilarDiffPair
+	Discarded  []SimilarDiffDiscard
+	Suppressed []SimilarDiffPair
+	Notes      []string
+}
+
+// OrderedPair is a pair that either survived or was discarded by the rules.
+type OrderedPair struct {
+	Pair      SimilarDiffPair
+	Discarded bool
+	Rules     []SimilarDiffChange
+}
+
+// Ordered returns the surviving and discarded pairs in the same order in
+// which the diff tool reported them.
+func (r Result) Ordered() []OrderedPair {
+	var d int
+
+	out := make([]OrderedPair, 0, len(r.Pairs)+len(r.Discarded))
+
+	for i := 0; i <= len(r.Pairs); i++ {
+		/* discarded pairs that belong before the current pair */
+		for ; d < len(r.Discarded) && r.Discarded[d].Position == i; d++ {
+			out = append(out, OrderedPair{
+				Pair:      r.Discarded[d].Pair,
+				Discarded: true,
+				Rules:     r.Discarded[d].Rules,
+			})
+		}
+
+		if i < len(r.Pairs) {
+			out = append(out, OrderedPair{Pair: r.Pairs[i]})
+		}
+	}
+
+	return out
+}
+
+// LineBased reports whether the differences are located by line number,
+// which is not the case for structured documents, tables and binary files.
+func (r Result) LineBased() bool {
+	for _, item := range r.Ordered() {
+		if item.Pair.Path != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Refilter applies the similarities of different options to the pairs of
+// the result, both the surviving and the discarded ones, without running the
+// diff tool again. The rules are selected like CompareFiles does, including
+// the tolerances and the language of file A.
+func (r Result) Refilter(opts Options) Result {
+	s := NewSimilarDiff()
+
+	for _, item := range r.Ordered() {
+		s.Pairs = append(s.Pairs, item.Pair)
+	}
+
+	s.Changes = opts.rulesFor(r.FileA)
+	s.Language = LanguageOf(r.FileA)
+
+	s.DiscardSimilarities()
+
+	return Result{
+		FileA:     r.FileA,
+		FileB:     r.FileB,
+		Pairs:     s.Pairs,
+		Discarded: s.Discarded,
+		Notes:     r.Notes,
+	}
+}
+
+// ResultDelta lists the differences that appeared and the ones that were
+// resolved between two runs of the same comparison.
+type ResultDelta struct {
+	Appeared []SimilarDiffPair
+	Resolved []SimilarDiffPair
+}
+
+// Delta compares the pairs of two results by content, ignoring the line
+// numbers, because editing a file shifts the position of every line below.
+func Delta(prev Result, next Result) ResultDelta {
+	var delta ResultDelta
+
+	seen := map[SimilarDiffPair]int{}
+
+	for _, pair := range prev.Pairs {
+		seen[contentOf(pair)]++
+	}
+
+	for _, pair := range next.Pairs {
+		key := contentOf(pair)
+
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+
+		delta.Appeared = append(delta.Appeared, pair)
+	}
+
+	for _, pair := range prev.Pairs {
+		key := contentOf(pair)
+
+		if seen[key] > 0 {
+			seen[key]--
+			delta.Resolved = append(delta.Resolved, pair)
+		}
+	}
+
+	return delta
+}
+
+// contentOf returns the pair without line numbers.
+func contentOf(pair SimilarDiffPair) SimilarDiffPair {
+	return SimilarDiffPair{Group: pair.Group, Left: pair.Left, Right: pair.Right, Path: pair.Path}
+}
+
+// Compare detects the differences between a and b, and discards the ones
+// that are explained by the similarities listed in the options.
+func Compare(a io.Reader, b io.Reader, opts Options) (Result, error) {
+	return CompareContext(context.Background(), a, b, opts)
+}
+
+// CompareContext is like Compare but stops the diff tool when the context is
+// done, see CompareFilesContext.
+func CompareContext(ctx context.Context, a io.Reader, b io.Reader, opts Options) (Result, error) {
+	fileA, err := tempCopy(a)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	defer os.Remove(fileA)
+
+	fileB, err := tempCopy(b)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	defer os.Remove(fileB)
+
+	result, err := CompareFilesContext(ctx, fileA, fileB, opts)
+
+	/* temporary names are meaningless to the caller */
+	result.FileA = ""
+	result.FileB = ""
+
+	return result, err
+}
+
+// CompareFiles detects the differences between two files, and discards the
+// ones that are explained by the similarities listed in the options. JSON
+// and YAML documents that cannot be parsed are compared line by line, with a
+// note in the result.
+func CompareFiles(fileA string, fileB string, opts Options) (Result, error) {
+	return CompareFilesContext(context.Background(), fileA, fileB, opts)
+}
+
+// CompareFilesContext is like CompareFiles but kills the diff tool if the
+// context is done before the tool ends, and returns the error of the context.
+func CompareFilesContext(ctx context.Context, fileA string, fileB string, opts Options) (Result, error) {
+	var fallback []string
+
+	if opts.Structural && IsStructured(fileA) && IsStructured(fileB) {
+		result, err := CompareStructured(fileA, fileB, opts)
+
+		var parseErr *ParseError
+
+		if !errors.As(err, &parseErr) {
+			return result, err
+		}
+
+		/* documents that are not strictly JSON or YAML are still text */
+		fallback = append(fallback, parseErr.Error()+"; compared line by line")
+	}
+
+	if opts.Tabular && IsTabular(fileA) && IsTabular(fileB) {
+		return CompareTables(fileA, fileB, opts)
+	}
+
+	for _, name := range []string{fileA, fileB} {
+		/* missing files are reported by the diff tool below */
+		if binary, _ := IsBinary(name); binary {
+			return CompareBinary(fileA, fileB, opts)
+		}
+	}
+
+	nameA, nameB, notes, cleanup, err := prepareFiles(fileA, fileB)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	defer cleanup()
+
+	s := NewSimilarDiff()
+
+	s.SetFileA(nameA)
+	s.SetFileB(nameB)
+	s.Changes = opts.rulesFor(fileA)
+	s.Language = LanguageOf(fileA)
+
+	/* read and run diff */
+	if err := s.FindChangesContext(ctx); err != nil {
+		return Result{}, err
+	}
+
+	s.CaptureChanges() /* find and process */
+
+	if opts.Moves {
+		s.DetectMoves()
+	}
+
+	s.DiscardSimilarities()
+
+	return Result{
+		FileA:     fileA,
+		FileB:     fileB,
+		Pairs:     s.Pairs,
+		Discarded: s.Discarded,
+		Notes:     append(fallback, notes...),
+	}, nil
+}
+
+// tempCopy writes the content of the reader into a temporary file, because
+// the diff tool only operates on files, and returns the name of the file.
+func tempCopy(r io.Reader) (string, error) {
+	file, err := os.CreateTemp("", "similardiff-*")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
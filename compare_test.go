@@ -0,0 +1,125 @@
+package similardiff
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	a := strings.NewReader("import foo\nsame line\npackage bar\nlorem\n")
+	b := strings.NewReader("include foo\nsame line\nmodule bar\nipsum\n")
+
+	result, err := Compare(a, b, Options{
+		Changes: []SimilarDiffChange{
+			{Old: "import", New: "include"},
+			{Old: "package", New: "module"},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{
+			Group:     'c',
+			Left:      "lorem",
+			Right:     "ipsum",
+			LeftLine:  4,
+			RightLine: 4,
+		},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+}
+
+func TestCompareNoTrailingNewline(t *testing.T) {
+	opts := Options{Changes: []SimilarDiffChange{{Old: "import", New: "include"}}}
+
+	result, err := Compare(strings.NewReader("x\nimport foo"), strings.NewReader("x\ninclude foo"), opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 0, nil)
+
+	if result, err = Compare(strings.NewReader("a\nb"), strings.NewReader("c\nd"), Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "a", Right: "c", LeftLine: 1, RightLine: 1},
+		{Group: 'c', Left: "b", Right: "d", LeftLine: 2, RightLine: 2},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 2, expected)
+}
+
+func TestCompareContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CompareContext(ctx, strings.NewReader("a\n"), strings.NewReader("b\n"), Options{})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expecting context.Canceled; got %#v", err)
+	}
+}
+
+func TestRefilter(t *testing.T) {
+	a := strings.NewReader("import foo\npackage bar\n")
+	b := strings.NewReader("include foo\nmodule bar\n")
+
+	result, err := Compare(a, b, Options{
+		Changes: []SimilarDiffChange{{Old: "import", New: "include"}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result = result.Refilter(Options{Changes: []SimilarDiffChange{{Old: "package", New: "module"}}})
+
+	expected := []SimilarDiffPair{
+		{
+			Group:     'c',
+			Left:      "import foo",
+			Right:     "include foo",
+			LeftLine:  1,
+			RightLine: 1,
+		},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	if len(result.Discarded) != 1 || result.Discarded[0].Pair.LeftLine != 2 {
+		t.Fatalf("unexpected discarded pairs: %#v", result.Discarded)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	prev := Result{Pairs: []SimilarDiffPair{
+		{Group: Changed, Left: "a", Right: "b", LeftLine: 1, RightLine: 1},
+		{Group: Deleted, Left: "c", LeftLine: 5},
+		{Group: Deleted, Left: "c", LeftLine: 9},
+	}}
+
+	next := Result{Pairs: []SimilarDiffPair{
+		{Group: Changed, Left: "a", Right: "b", LeftLine: 3, RightLine: 3},
+		{Group: Deleted, Left: "c", LeftLine: 7},
+		{Group: Added, Right: "d", RightLine: 8},
+	}}
+
+	delta := Delta(prev, next)
+
+	if len(delta.Appeared) != 1 || delta.Appeared[0].Right != "d" {
+		t.Fatalf("unexpected appeared pairs: %#v", delta.Appeared)
+	}
+
+	if len(delta.Resolved) != 1 || delta.Resolved[0].LeftLine != 5 {
+		t.Fatalf("unexpected resolved pairs: %#v", delta.Resolved)
+	}
+}
@@ -0,0 +1,261 @@
+package similardiff
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config is the content of a configuration file.
+//
+// Rules at the top of the file apply everywhere. Rules that follow a
+// "[column NAME]" section header only apply to that column when comparing
+// CSV or TSV files. Rules that follow a "[bytes]" section header are byte
+// patterns written in hexadecimal, for example "de ad=be ef", used by the
+// byte-level comparison of binary files. Rules that follow a "[word]",
+// "[identifier]", "[keyword]" or "[string]" section header are global rules
+// of that kind, which do not modify parts of longer words. Every line that
+// follows a "[pattern]" section header is a template, see KindPattern.
+//
+// Rules that follow a "[tolerance GLOB]" section header are numeric
+// tolerances, for example "relative=0.01", for the files that match the
+// pattern, or for every file if the pattern is omitted; see KindTolerance.
+//
+// The "[lsp]" section holds the settings of the language server, where
+// "counterpart=../c-src/{name}.c" locates the file compared with every
+// document, see CounterpartOf.
+type Config struct {
+	Changes     []SimilarDiffChange
+	Columns     map[string][]SimilarDiffChange
+	Bytes       []SimilarDiffChange
+	Tolerances  map[string][]SimilarDiffChange
+	Counterpart string
+}
+
+// LoadChanges reads a list of similarities from a configuration file.
+//
+// Every line is expected to follow the format "old=new", empty lines and
+// lines starting with a hash are ignored. A missing file yields no changes.
+func LoadChanges(filename string) ([]SimilarDiffChange, error) {
+	config, err := LoadConfig(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Changes, nil
+}
+
+// LoadConfig reads a configuration file, including its sections. A missing
+// file yields an empty configuration.
+func LoadConfig(filename string) (Config, error) {
+	var config Config
+
+	/* configuration file does not exists; skip changes */
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return config, nil
+	}
+
+	file, err := os.Open(filename)
+
+	if err != nil {
+		return config, &ConfigError{Name: filename, Err: err}
+	}
+
+	defer file.Close()
+
+	var line string
+	var parts []string
+	var number int
+	var section string
+	var column string
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		number++
+		line = scanner.Text()
+		line = strings.TrimSpace(line)
+
+		/* section header */
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.Fields(line[1 : len(line)-1])
+
+			switch {
+			case len(name) == 2 && name[0] == "column":
+				section, column = name[0], name[1]
+			case len(name) == 1 && name[0] == "bytes":
+				section, column = name[0], ""
+			case (len(name) == 1 || len(name) == 2) && name[0] == KindTolerance:
+				section, column = name[0], "*"
+				if len(name) == 2 {
+					column = name[1]
+				}
+			case len(name) == 1 && name[0] == "lsp":
+				section, column = name[0], ""
+			case len(name) == 1 && isRuleKind(name[0]):
+				section, column = name[0], ""
+			default:
+				return config, &ConfigError{Name: filename, Line: number, Err: fmt.Errorf("unknown section %s", line)}
+			}
+
+			continue
+		}
+
+		/* expect x=y */
+		if len(line) < 3 {
+			continue
+		}
+
+		/* skip comments */
+		if line[0] == '#' {
+			continue
+		}
+
+		/* templates are written as they are, without replacement */
+		if section == KindPattern {
+			if _, err := CompilePattern(line); err != nil {
+				return config, &ConfigError{Name: filename, Line: number, Err: err}
+			}
+
+			config.Changes = append(config.Changes, SimilarDiffChange{Old: line, Kind: KindPattern})
+			continue
+		}
+
+		parts = strings.Split(scanner.Text(), "=")
+
+		if len(parts) < 2 {
+			return config, &ConfigError{Name: filename, Line: number, Err: ErrMalformedRule}
+		}
+
+		change := SimilarDiffChange{
+			Old: parts[0],
+			New: parts[1],
+		}
+
+		if section == "lsp" {
+			if strings.TrimSpace(parts[0]) != "counterpart" {
+				return config, &ConfigError{Name: filename, Line: number, Err: fmt.Errorf("unknown setting %s", parts[0])}
+			}
+
+			config.Counterpart = strings.TrimSpace(parts[1])
+			continue
+		}
+
+		if section == KindTolerance {
+			if change, err = parseTolerance(change); err != nil {
+				return config, &ConfigError{Name: filename, Line: number, Err: err}
+			}
+
+			if config.Tolerances == nil {
+				config.Tolerances = map[string][]SimilarDiffChange{}
+			}
+
+			config.Tolerances[column] = append(config.Tolerances[column], change)
+			continue
+		}
+
+		if section == "bytes" {
+			if change, err = parseByteChange(change); err != nil {
+				return config, &ConfigError{Name: filename, Line: number, Err: err}
+			}
+
+			config.Bytes = append(config.Bytes, change)
+			continue
+		}
+
+		if isRuleKind(section) {
+			change.Kind = section
+		}
+
+		if column == "" {
+			config.Changes = append(config.Changes, change)
+			continue
+		}
+
+		if config.Columns == nil {
+			config.Columns = map[string][]SimilarDiffChange{}
+		}
+
+		config.Columns[column] = append(config.Columns[column], change)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return config, &ConfigError{Name: filename, Err: err}
+	}
+
+	return config, nil
+}
+
+func isRuleKind(name string) bool {
+	switch name {
+	case KindWord, KindIdentifier, KindKeyword, KindString, KindPattern:
+		return true
+	}
+
+	return false
+}
+
+// parseByteChange decodes both sides of a byte pattern rule.
+func parseByteChange(change SimilarDiffChange) (SimilarDiffChange, error) {
+	old, err := ParseBytePattern(change.Old)
+
+	if err != nil {
+		return change, err
+	}
+
+	new, err := ParseBytePattern(change.New)
+
+	if err != nil {
+		return change, err
+	}
+
+	return SimilarDiffChange{Old: old, New: new}, nil
+}
+
+// AppendChange adds a similarity to the global rules of a configuration
+// file; the file is created if it does not exist. The rule is written before
+// the first section, if any, so that it does not become a column rule. Rules
+// with a kind are written at the end, in a section of their kind.
+func AppendChange(filename string, change SimilarDiffChange) error {
+	if change.Old == "" || (change.Kind != KindPattern && strings.Contains(change.Old, "=")) || strings.Contains(change.Old+change.New, "\n") {
+		return &ConfigError{Name: filename, Err: ErrMalformedRule}
+	}
+
+	data, err := os.ReadFile(filename)
+
+	if err != nil && !os.IsNotExist(err) {
+		return &ConfigError{Name: filename, Err: err}
+	}
+
+	rule := change.String() + "\n"
+	lines := strings.SplitAfter(string(data), "\n")
+	content := string(data)
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	if change.Kind != "" {
+		content += fmt.Sprintf("[%s]\n%s", change.Kind, rule)
+		lines = nil
+	} else {
+		content += rule
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			content = strings.Join(lines[:i], "") + rule + strings.Join(lines[i:], "")
+			break
+		}
+	}
+
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		return &ConfigError{Name: filename, Err: err}
+	}
+
+	return nil
+}
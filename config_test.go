@@ -0,0 +1,35 @@
+package similardiff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigColumns(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "similardiff.ini")
+
+	content := "import=include\n[column env]\nprod=stage\n"
+
+	if err := os.WriteFile(config, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AppendChange(config, SimilarDiffChange{Old: "package", New: "module"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Changes) != 2 || c.Changes[1] != (SimilarDiffChange{Old: "package", New: "module"}) {
+		t.Fatalf("unexpected global rules: %#v", c.Changes)
+	}
+
+	if len(c.Columns["env"]) != 1 || c.Columns["env"][0] != (SimilarDiffChange{Old: "prod", New: "stage"}) {
+		t.Fatalf("unexpected column rules: %#v", c.Columns)
+	}
+}
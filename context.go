@@ -0,0 +1,112 @@
+package similardiff
+
+// contextHunk is a group of surviving pairs whose context lines touch or
+// overlap; first and last are the lines of file A printed around them.
+type contextHunk struct {
+	first int
+	last  int
+	pairs []SimilarDiffPair
+	spans [][2]int
+}
+
+// printContext writes the pairs of the result surrounded by the lines of
+// file A around them. It returns false, without writing anything, if the
+// pairs are not located by line or file A cannot be read.
+func (p *Printer) printContext(r Result) bool {
+	if r.FileA == "" {
+		return false
+	}
+
+	for _, pair := range r.Pairs {
+		if pair.Path != "" {
+			return false
+		}
+	}
+
+	/* the same lines, in UTF-8 and without CR, that were compared */
+	lines, err := readLines(r.FileA)
+
+	if err != nil {
+		return false
+	}
+
+	for i, hunk := range contextHunks(r.Pairs, p.Context, len(lines)) {
+		if i > 0 || hunk.first > 1 {
+			p.PrintCyan("@@ %d-%d @@", hunk.first, hunk.last)
+		}
+
+		next := hunk.first
+
+		for j, pair := range hunk.pairs {
+			p.printLines(lines, next, hunk.spans[j][0]-1)
+			p.PrintPair(pair)
+			next = hunk.spans[j][1] + 1
+		}
+
+		p.printLines(lines, next, hunk.last)
+	}
+
+	return true
+}
+
+// printLines writes the unchanged lines of file A, with a space instead of
+// the signs that mark the differences.
+func (p *Printer) printLines(lines []string, first int, last int) {
+	for n := first; n <= last && n <= len(lines); n++ {
+		p.printColor("", "%d\t %s", n, lines[n-1])
+	}
+}
+
+// contextHunks groups the pairs whose context lines touch or overlap. Every
+// pair spans some lines of file A; added lines span no lines and are
+// anchored after the preceding line of file A.
+func contextHunks(pairs []SimilarDiffPair, context int, total int) []contextHunk {
+	var hunks []contextHunk
+
+	for _, pair := range pairs {
+		span := pairSpan(pair)
+		first := span[0] - context
+		last := span[1] + context
+
+		if first < 1 {
+			first = 1
+		}
+
+		if last > total {
+			last = total
+		}
+
+		if n := len(hunks); n > 0 && first <= hunks[n-1].last+1 {
+			hunk := &hunks[n-1]
+			hunk.pairs = append(hunk.pairs, pair)
+			hunk.spans = append(hunk.spans, span)
+
+			if last > hunk.last {
+				hunk.last = last
+			}
+			continue
+		}
+
+		hunks = append(hunks, contextHunk{
+			first: first,
+			last:  last,
+			pairs: []SimilarDiffPair{pair},
+			spans: [][2]int{span},
+		})
+	}
+
+	return hunks
+}
+
+// pairSpan returns the first and last lines of file A occupied by a pair.
+// Added lines occupy no lines; the span ends at the line they follow.
+func pairSpan(pair SimilarDiffPair) [2]int {
+	switch pair.Group {
+	case Added:
+		return [2]int{pair.AfterLine + 1, pair.AfterLine}
+	case Moved:
+		return [2]int{pair.LeftLine, pair.LeftLine + MovedLines(pair) - 1}
+	}
+
+	return [2]int{pair.LeftLine, pair.LeftLine}
+}
@@ -0,0 +1,144 @@
+package similardiff
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintContext(t *testing.T) {
+	var a, b []string
+
+	for i := 1; i <= 30; i++ {
+		a = append(a, "line "+strings.Repeat("i", i%7))
+	}
+
+	b = append(b, a...)
+	b[4] = "five"
+	b[24] = "x"
+	b = append(b[:20], append([]string{"added"}, b[20:]...)...)
+	b = append(b[:11], b[12:]...)
+
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": strings.Join(a, "\n") + "\n",
+		"b.txt": strings.Join(b, "\n") + "\n",
+	})
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	p := NewPrinter(&buf)
+	p.SetContext(2)
+	p.PrettyPrint(result)
+
+	expected := []string{
+		"@@ 3-7 @@",
+		"3\t line iii",
+		"4\t line iiii",
+		"5\t-line iiiii",
+		"5\t+five",
+		"6\t line iiiiii",
+		"7\t line ",
+		"@@ 10-14 @@",
+		"10\t line iii",
+		"11\t line iiii",
+		"12\t-line iiiii",
+		"13\t line iiiiii",
+		"14\t line ",
+		"@@ 19-27 @@",
+		"19\t line iiiii",
+		"20\t line iiiiii",
+		"20\t+added",
+		"21\t line ",
+		"22\t line i",
+		"23\t line ii",
+		"24\t line iii",
+		"25\t-line iiii",
+		"25\t+x",
+		"26\t line iiiii",
+		"27\t line iiiiii",
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")[2:]
+
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestPrintContextBaseline(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"b.txt": "NEW0\n1\n2\n3\n4\n5\nNEW1\n6\n7\n8\n9\n10\n",
+	})
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* the first added line was accepted, so it no longer precedes NEW1 */
+	baseline := NewBaseline([]Result{{FileA: result.FileA, Pairs: result.Pairs[:1]}})
+	result = baseline.Filter(result)
+
+	var buf bytes.Buffer
+
+	p := NewPrinter(&buf)
+	p.SetContext(1)
+	p.PrettyPrint(result)
+
+	expected := []string{
+		"@@ 5-6 @@",
+		"5\t 5",
+		"7\t+NEW1",
+		"6\t 6",
+	}
+
+	if !strings.HasSuffix(buf.String(), strings.Join(expected, "\n")+"\n") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestPrintContextEncodings(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "one\rtwo\rth\xe9\rfour\r",
+		"b.txt": "one\ntwo\nthé\nFOUR\n",
+	})
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	p := NewPrinter(&buf)
+	p.SetContext(2)
+	p.PrettyPrint(result)
+
+	expected := []string{
+		"@@ 2-4 @@",
+		"2\t two",
+		"3\t thé",
+		"4\t-four",
+		"4\t+FOUR",
+	}
+
+	if !strings.HasSuffix(buf.String(), strings.Join(expected, "\n")+"\n") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
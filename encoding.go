@@ -0,0 +1,334 @@
+package similardiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Character sets detected by DetectEncoding.
+const (
+	UTF8    = "UTF-8"
+	UTF8BOM = "UTF-8 with BOM"
+	UTF16LE = "UTF-16LE"
+	UTF16BE = "UTF-16BE"
+	Latin1  = "Latin-1"
+)
+
+// Line endings detected by DetectEncoding.
+const (
+	EndingLF    = "LF"
+	EndingCRLF  = "CRLF"
+	EndingCR    = "CR"
+	EndingMixed = "mixed"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// Encoding describes the character set and the line endings of a file. The
+// line ending is empty if the file has a single line.
+type Encoding struct {
+	Charset    string
+	LineEnding string
+}
+
+// DetectEncoding inspects the beginning of a file to find out its character
+// set, using the byte order mark if any, and its line endings. Files without
+// a byte order mark that are not valid UTF-8 are assumed to be Latin-1.
+func DetectEncoding(name string) (Encoding, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return Encoding{}, err
+	}
+
+	defer file.Close()
+
+	sample := make([]byte, binaryProbe)
+	n, err := io.ReadFull(file, sample)
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Encoding{}, err
+	}
+
+	sample = sample[:n]
+
+	enc := Encoding{Charset: detectCharset(sample)}
+
+	/* inspect the line endings of the decoded text */
+	var text bytes.Buffer
+
+	if err := decodeText(&text, bytes.NewReader(sample), enc.Charset); err != nil {
+		return enc, err
+	}
+
+	enc.LineEnding = detectLineEnding(text.Bytes())
+
+	return enc, nil
+}
+
+// needsNormalization reports whether the file must be converted to UTF-8
+// with LF line endings before it is compared.
+func (e Encoding) needsNormalization() bool {
+	return e.Charset != UTF8 || (e.LineEnding != "" && e.LineEnding != EndingLF)
+}
+
+func detectCharset(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return UTF8BOM
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return UTF16BE
+	}
+
+	/* the sample may end in the middle of a multi-byte character */
+	for i := 0; i < utf8.UTFMax && len(sample) > 0; i++ {
+		if utf8.Valid(sample) {
+			return UTF8
+		}
+
+		if len(sample) < binaryProbe {
+			break
+		}
+
+		sample = sample[:len(sample)-1]
+	}
+
+	if utf8.Valid(sample) {
+		return UTF8
+	}
+
+	return Latin1
+}
+
+func detectLineEnding(text []byte) string {
+	crlf := bytes.Count(text, []byte("\r\n"))
+	cr := bytes.Count(text, []byte("\r")) - crlf
+	lf := bytes.Count(text, []byte("\n")) - crlf
+
+	var found []string
+
+	if lf > 0 {
+		found = append(found, EndingLF)
+	}
+
+	if crlf > 0 {
+		found = append(found, EndingCRLF)
+	}
+
+	if cr > 0 {
+		found = append(found, EndingCR)
+	}
+
+	switch len(found) {
+	case 0:
+		return ""
+	case 1:
+		return found[0]
+	}
+
+	return EndingMixed
+}
+
+// decodeText converts the text into UTF-8, without the byte order mark.
+func decodeText(w io.Writer, r io.Reader, charset string) error {
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
+
+	switch charset {
+	case UTF8BOM, UTF16LE, UTF16BE:
+		bom := len(bomUTF16LE)
+
+		if charset == UTF8BOM {
+			bom = len(bomUTF8)
+		}
+
+		if _, err := reader.Discard(bom); err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	switch charset {
+	case UTF16LE, UTF16BE:
+		var units []uint16
+
+		buf := make([]byte, 2)
+
+		for {
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				break /* an odd trailing byte is dropped */
+			}
+
+			if charset == UTF16LE {
+				units = append(units, uint16(buf[0])|uint16(buf[1])<<8)
+			} else {
+				units = append(units, uint16(buf[1])|uint16(buf[0])<<8)
+			}
+
+			/* flush complete characters; keep a pending high surrogate */
+			if last := units[len(units)-1]; last < 0xd800 || last > 0xdbff {
+				writer.WriteString(string(utf16.Decode(units)))
+				units = units[:0]
+			}
+		}
+
+		writer.WriteString(string(utf16.Decode(units)))
+	case Latin1:
+		for {
+			b, err := reader.ReadByte()
+
+			if err != nil {
+				break
+			}
+
+			writer.WriteRune(rune(b))
+		}
+	default:
+		if _, err := io.Copy(writer, reader); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// normalizeFile writes a copy of the file in UTF-8 with LF line endings into
+// a temporary file, and returns the name of the temporary file.
+func normalizeFile(name string, enc Encoding) (string, error) {
+	in, err := os.Open(name)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer in.Close()
+
+	out, err := os.CreateTemp("", "similardiff-*")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer out.Close()
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(decodeText(pw, in, enc.Charset))
+	}()
+
+	if err := normalizeLineEndings(out, pr); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+
+	return out.Name(), nil
+}
+
+// normalizeLineEndings replaces CRLF and lone CR with LF.
+func normalizeLineEndings(w io.Writer, r io.Reader) error {
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
+
+	for {
+		b, err := reader.ReadByte()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if b == '\r' {
+			if next, err := reader.Peek(1); err == nil && next[0] == '\n' {
+				continue /* the LF is written in the next iteration */
+			}
+
+			b = '\n'
+		}
+
+		if err := writer.WriteByte(b); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// prepareFiles detects the encoding of both files and, if necessary, writes
+// normalized copies of them. It returns the names of the files that must be
+// compared, the notes describing any difference in encoding or line endings,
+// and a function that removes the temporary files.
+func prepareFiles(fileA string, fileB string) (string, string, []string, func(), error) {
+	if err := checkFiles(fileA, fileB); err != nil {
+		return "", "", nil, nil, err
+	}
+
+	for _, name := range []string{fileA, fileB} {
+		/* the diff tool compares directories on its own */
+		if info, err := os.Stat(name); err == nil && info.IsDir() {
+			return fileA, fileB, nil, func() {}, nil
+		}
+	}
+
+	var notes []string
+	var temps []string
+
+	cleanup := func() {
+		for _, name := range temps {
+			os.Remove(name)
+		}
+	}
+
+	names := []string{fileA, fileB}
+	encodings := make([]Encoding, 2)
+
+	for i, name := range names {
+		enc, err := DetectEncoding(name)
+
+		if err != nil {
+			cleanup()
+			return "", "", nil, nil, err
+		}
+
+		encodings[i] = enc
+
+		if !enc.needsNormalization() {
+			continue
+		}
+
+		temp, err := normalizeFile(name, enc)
+
+		if err != nil {
+			cleanup()
+			return "", "", nil, nil, err
+		}
+
+		temps = append(temps, temp)
+		names[i] = temp
+	}
+
+	a, b := encodings[0], encodings[1]
+
+	if a.Charset != b.Charset {
+		notes = append(notes, fmt.Sprintf("encoding differs: %s in file A, %s in file B", a.Charset, b.Charset))
+	}
+
+	if a.LineEnding != b.LineEnding && a.LineEnding != "" && b.LineEnding != "" {
+		notes = append(notes, fmt.Sprintf("line endings differ: %s in file A, %s in file B", a.LineEnding, b.LineEnding))
+	}
+
+	return names[0], names[1], notes, cleanup, nil
+}
@@ -0,0 +1,75 @@
+package similardiff
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"utf8.txt":   "héllo\nworld\n",
+		"bom.txt":    "\xef\xbb\xbfhello\r\nworld\r\n",
+		"utf16.txt":  "\xff\xfeh\x00i\x00\r\x00\n\x00",
+		"latin1.txt": "h\xe9llo\rworld\r",
+		"mixed.txt":  "one\r\ntwo\n",
+		"single.txt": "one",
+	})
+
+	tests := map[string]Encoding{
+		"utf8.txt":   {Charset: UTF8, LineEnding: EndingLF},
+		"bom.txt":    {Charset: UTF8BOM, LineEnding: EndingCRLF},
+		"utf16.txt":  {Charset: UTF16LE, LineEnding: EndingCRLF},
+		"latin1.txt": {Charset: Latin1, LineEnding: EndingCR},
+		"mixed.txt":  {Charset: UTF8, LineEnding: EndingMixed},
+		"single.txt": {Charset: UTF8},
+	}
+
+	for name, expected := range tests {
+		enc, err := DetectEncoding(filepath.Join(dir, name))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if enc != expected {
+			t.Fatalf("%s: expecting %#v; got %#v", name, expected, enc)
+		}
+	}
+
+	if binary, _ := IsBinary(filepath.Join(dir, "utf16.txt")); binary {
+		t.Fatal("UTF-16 text must not be a binary file")
+	}
+}
+
+func TestCompareEncodings(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "\xff\xfec\x00a\x00f\x00\xe9\x00\r\x00\n\x00o\x00n\x00e\x00\r\x00\n\x00",
+		"b.txt": "café\ntwo\n",
+	})
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "one", Right: "two", LeftLine: 2, RightLine: 2},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	notes := []string{
+		"encoding differs: UTF-16LE in file A, UTF-8 in file B",
+		"line endings differ: CRLF in file A, LF in file B",
+	}
+
+	if !reflect.DeepEqual(result.Notes, notes) {
+		t.Fatalf("unexpected notes: %q", result.Notes)
+	}
+}
@@ -0,0 +1,79 @@
+package similardiff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMalformedRule is reported when a configuration line is not "old=new".
+var ErrMalformedRule = errors.New("malformed rule; expecting old=new")
+
+// ErrNotLineBased is reported when an operation that works line by line is
+// given a comparison whose differences are located by path or byte offset,
+// like the ones of structured documents, tables and binary files.
+var ErrNotLineBased = errors.New("differences are not located by line")
+
+// MissingFileError is returned when one of the compared files does not exist.
+type MissingFileError struct {
+	Name string
+}
+
+func (e *MissingFileError) Error() string {
+	return fmt.Sprintf("missing file: %s", e.Name)
+}
+
+// ConfigError is returned when the configuration file cannot be read or
+// contains a line that cannot be interpreted. Line is zero when the error
+// is not associated to a specific line.
+type ConfigError struct {
+	Name string
+	Line int
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("config %s:%d: %s", e.Name, e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("config %s: %s", e.Name, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// DiffError is returned when the diff tool fails to run or exits with a
+// status code that means trouble; exit(1) only means there are differences.
+type DiffError struct {
+	Tool   string
+	Code   int
+	Stderr string
+	Err    error
+}
+
+func (e *DiffError) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("%s failed (exit %d): %s", e.Tool, e.Code, e.Stderr)
+	}
+
+	return fmt.Sprintf("%s failed (exit %d): %s", e.Tool, e.Code, e.Err)
+}
+
+func (e *DiffError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned when a structured document cannot be decoded.
+type ParseError struct {
+	Name string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("cannot parse %s: %s", e.Name, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,67 @@
+package similardiff
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.txt")
+
+	if err := os.WriteFile(fileA, []byte("A\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := CompareFiles(fileA, filepath.Join(dir, "b.txt"), Options{})
+
+	var missing *MissingFileError
+
+	if !errors.As(err, &missing) {
+		t.Fatalf("expecting MissingFileError; got %#v", err)
+	}
+}
+
+func TestMalformedConfiguration(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "similardiff.ini")
+
+	if err := os.WriteFile(config, []byte("# comment\nimport=include\npackage:module\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadChanges(config)
+
+	var configErr *ConfigError
+
+	if !errors.As(err, &configErr) {
+		t.Fatalf("expecting ConfigError; got %#v", err)
+	}
+
+	if configErr.Line != 3 || !errors.Is(err, ErrMalformedRule) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestDiffToolFailure(t *testing.T) {
+	dir := t.TempDir()
+	fileB := filepath.Join(dir, "b.txt")
+
+	if err := os.WriteFile(fileB, []byte("B\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	/* the directory does not contain a file with the same name */
+	_, err := CompareFiles(t.TempDir(), fileB, Options{})
+
+	var diffErr *DiffError
+
+	if !errors.As(err, &diffErr) {
+		t.Fatalf("expecting DiffError; got %#v", err)
+	}
+
+	if diffErr.Code != 2 {
+		t.Fatalf("unexpected exit code: %d", diffErr.Code)
+	}
+}
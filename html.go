@@ -0,0 +1,219 @@
+package similardiff
+
+import (
+	"html/template"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// htmlFile is the view of one comparison in the HTML report.
+type htmlFile struct {
+	FileA     string
+	FileB     string
+	Pairs     int
+	Discarded int
+	Notes     []string
+	Rows      []htmlRow
+}
+
+// htmlRow is either a surviving pair or a collapsed group of discarded pairs.
+type htmlRow struct {
+	Pair    *htmlPair
+	Similar []htmlPair
+	Rules   []SimilarDiffChange
+}
+
+// htmlPair is one line of the side-by-side table, each side is split in the
+// text shared by both lines and the text that differs.
+type htmlPair struct {
+	Group     string
+	LeftLine  int
+	RightLine int
+	Left      [3]string
+	Right     [3]string
+}
+
+// WriteHTML writes a self-contained HTML page with a side-by-side view of
+// the results. Discarded similarities are grouped in collapsible sections
+// that list the rules explaining them.
+func WriteHTML(w io.Writer, results []Result) error {
+	var data struct {
+		Files     []htmlFile
+		Pairs     int
+		Discarded int
+	}
+
+	for _, r := range results {
+		file := newHTMLFile(r)
+		data.Pairs += file.Pairs
+		data.Discarded += file.Discarded
+		data.Files = append(data.Files, file)
+	}
+
+	return htmlReport.Execute(w, data)
+}
+
+func newHTMLFile(r Result) htmlFile {
+	file := htmlFile{
+		FileA:     r.FileA,
+		FileB:     r.FileB,
+		Pairs:     len(r.Pairs),
+		Discarded: len(r.Discarded),
+		Notes:     r.Notes,
+	}
+
+	for _, item := range r.Ordered() {
+		if !item.Discarded {
+			pair := newHTMLPair(item.Pair)
+			file.Rows = append(file.Rows, htmlRow{Pair: &pair})
+			continue
+		}
+
+		/* consecutive discarded pairs share the same section */
+		if n := len(file.Rows); n == 0 || file.Rows[n-1].Pair != nil {
+			file.Rows = append(file.Rows, htmlRow{})
+		}
+
+		row := &file.Rows[len(file.Rows)-1]
+		row.Similar = append(row.Similar, newHTMLPair(item.Pair))
+		row.Rules = appendRules(row.Rules, item.Rules)
+	}
+
+	return file
+}
+
+func newHTMLPair(pair SimilarDiffPair) htmlPair {
+	out := htmlPair{
+		Group:     string(pair.Group),
+		LeftLine:  pair.LeftLine,
+		RightLine: pair.RightLine,
+	}
+
+	if pair.Group != Changed {
+		out.Left[1] = pair.Left
+		out.Right[1] = pair.Right
+		return out
+	}
+
+	prefix, left, right, suffix := splitDifference(pair.Left, pair.Right)
+	out.Left = [3]string{prefix, left, suffix}
+	out.Right = [3]string{prefix, right, suffix}
+
+	return out
+}
+
+// appendRules adds the rules that are not in the list yet.
+func appendRules(list []SimilarDiffChange, rules []SimilarDiffChange) []SimilarDiffChange {
+	for _, rule := range rules {
+		found := false
+
+		for _, item := range list {
+			if item == rule {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			list = append(list, rule)
+		}
+	}
+
+	return list
+}
+
+// splitDifference returns the prefix and suffix shared by both lines, and the
+// text in the middle of each line that is different.
+func splitDifference(a string, b string) (string, string, string, string) {
+	var prefix int
+	var suffix int
+
+	for prefix < len(a) && prefix < len(b) {
+		r, size := utf8.DecodeRuneInString(a[prefix:])
+
+		if !strings.HasPrefix(b[prefix:], string(r)) {
+			break
+		}
+
+		prefix += size
+	}
+
+	for suffix < len(a)-prefix && suffix < len(b)-prefix {
+		r, size := utf8.DecodeLastRuneInString(a[:len(a)-suffix])
+
+		if !strings.HasSuffix(b[:len(b)-suffix], string(r)) {
+			break
+		}
+
+		suffix += size
+	}
+
+	return a[:prefix], a[prefix : len(a)-suffix], b[prefix : len(b)-suffix], a[len(a)-suffix:]
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Similar Diff</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #24292e; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; table-layout: fixed; }
+td { font-family: monospace; white-space: pre-wrap; word-break: break-all; padding: 0 .5em; vertical-align: top; }
+td.num { width: 4em; text-align: right; color: #6a737d; }
+tr.c td.left, tr.d td.left { background: #ffeef0; }
+tr.c td.right, tr.a td.right { background: #e6ffed; }
+tr.m td.left, tr.m td.right { background: #f1f8ff; }
+td.left del { background: #fdb8c0; text-decoration: none; }
+td.right ins { background: #acf2bd; text-decoration: none; }
+details { margin: .25em 0; }
+summary { cursor: pointer; color: #6a737d; font-size: .9em; }
+details table td { color: #6a737d; }
+.summary { color: #6a737d; }
+</style>
+</head>
+<body>
+<h1>Similar Diff</h1>
+<p class="summary">{{.Pairs}} differences, {{.Discarded}} similarities discarded, {{len .Files}} files compared.</p>
+{{range .Files}}
+<h2>--- {{.FileA}}<br>+++ {{.FileB}}</h2>
+<p class="summary">{{.Pairs}} differences, {{.Discarded}} similarities discarded.</p>
+{{- range .Notes}}
+<p class="summary">{{.}}</p>
+{{- end}}
+<table>
+{{- range .Rows}}
+{{- if .Pair}}
+{{- with .Pair}}
+<tr class="{{.Group}}">
+<td class="num">{{if .LeftLine}}{{.LeftLine}}{{end}}</td>
+<td class="left">{{index .Left 0}}<del>{{index .Left 1}}</del>{{index .Left 2}}</td>
+<td class="num">{{if .RightLine}}{{.RightLine}}{{end}}</td>
+<td class="right">{{index .Right 0}}<ins>{{index .Right 1}}</ins>{{index .Right 2}}</td>
+</tr>
+{{- end}}
+{{- else}}
+<tr><td colspan="4">
+<details>
+<summary>{{len .Similar}} similar lines discarded by{{range .Rules}} <code>{{.}}</code>{{end}}</summary>
+<table>
+{{- range .Similar}}
+<tr>
+<td class="num">{{.LeftLine}}</td>
+<td>{{index .Left 0}}{{index .Left 1}}{{index .Left 2}}</td>
+<td class="num">{{.RightLine}}</td>
+<td>{{index .Right 0}}{{index .Right 1}}{{index .Right 2}}</td>
+</tr>
+{{- end}}
+</table>
+</details>
+</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,64 @@
+package similardiff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSplitDifference(t *testing.T) {
+	tests := []struct {
+		a, b                       string
+		prefix, midA, midB, suffix string
+	}{
+		{"import foo", "include foo", "i", "mport", "nclude", " foo"},
+		{"same", "same", "same", "", "", ""},
+		{"aaa", "aa", "aa", "a", "", ""},
+		{"año 1", "año 2", "año ", "1", "2", ""},
+	}
+
+	for _, test := range tests {
+		prefix, midA, midB, suffix := splitDifference(test.a, test.b)
+
+		if prefix != test.prefix || midA != test.midA || midB != test.midB || suffix != test.suffix {
+			t.Fatalf("%q vs %q: got %q %q %q %q", test.a, test.b, prefix, midA, midB, suffix)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	a := strings.NewReader("import foo\n<b>foo</b>\n")
+	b := strings.NewReader("include foo\n<b>bar</b>\n")
+
+	result, err := Compare(a, b, Options{
+		Changes: []SimilarDiffChange{{Old: "import", New: "include"}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result.FileA = "a.txt"
+	result.FileB = "b.txt"
+
+	var buf bytes.Buffer
+
+	if err := WriteHTML(&buf, []Result{result}); err != nil {
+		t.Fatal(err)
+	}
+
+	page := buf.String()
+
+	for _, expected := range []string{
+		"--- a.txt",
+		"+++ b.txt",
+		"1 differences, 1 similarities discarded",
+		"<code>import=include</code>",
+		"&lt;b&gt;<del>foo</del>&lt;/b&gt;",
+		"&lt;b&gt;<ins>bar</ins>&lt;/b&gt;",
+	} {
+		if !strings.Contains(page, expected) {
+			t.Fatalf("missing %q in:\n%s", expected, page)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package similardiff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes a JUnit XML report with one test case per compared
+// file. A test case fails when differences survive the similarity rules,
+// and the failure lists them in the same format used by PrettyPrint. Every
+// file that could not be compared is a test case too; missing files are
+// failures and the comparisons that failed are errors.
+func WriteJUnit(w io.Writer, results []Result, failures []Failure) error {
+	suite := junitSuite{Name: "similardiff"}
+
+	for _, r := range results {
+		c := junitCase{ClassName: "similardiff", Name: r.FileA}
+
+		if len(r.Pairs) > 0 {
+			var text strings.Builder
+
+			p := NewPrinter(&text)
+
+			p.PrintHeader(r.FileA, r.FileB)
+
+			for _, pair := range r.Pairs {
+				p.PrintPair(pair)
+			}
+
+			c.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d differences with %s", len(r.Pairs), r.FileB),
+				Type:    "difference",
+				Text:    text.String(),
+			}
+
+			suite.Failures++
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	for _, f := range failures {
+		c := junitCase{ClassName: "similardiff", Name: f.FileA}
+		problem := &junitFailure{Message: f.Err.Error(), Type: f.kind(), Text: f.Err.Error()}
+
+		if f.kind() == "missing" {
+			c.Failure = problem
+			suite.Failures++
+		} else {
+			c.Error = problem
+			suite.Errors++
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	report := junitSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Suites:   []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,76 @@
+package similardiff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	results := []Result{
+		{FileA: "a/one.txt", FileB: "b/one.txt"},
+		{FileA: "a/two.txt", FileB: "b/two.txt", Pairs: []SimilarDiffPair{
+			{Group: Changed, Left: "x <", Right: "y", LeftLine: 3, RightLine: 3},
+		}},
+	}
+
+	var buf bytes.Buffer
+
+	if err := WriteJUnit(&buf, results, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var report junitSuites
+
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Tests != 2 || report.Failures != 1 || len(report.Suites[0].Cases) != 2 {
+		t.Fatalf("unexpected report: %#v", report)
+	}
+
+	if c := report.Suites[0].Cases[0]; c.Name != "a/one.txt" || c.Failure != nil {
+		t.Fatalf("unexpected test case: %#v", c)
+	}
+
+	failure := report.Suites[0].Cases[1].Failure
+
+	if failure == nil || !strings.Contains(failure.Text, "3\t-x <\n3\t+y\n") {
+		t.Fatalf("unexpected failure: %#v", failure)
+	}
+}
+
+func TestWriteJUnitFailures(t *testing.T) {
+	failures := []Failure{
+		{FileA: "a/one.txt", FileB: "b/one.txt", Err: &DiffError{Tool: "diff", Code: 2, Stderr: "boom"}},
+		{FileA: "a/two.txt", FileB: "b/two.txt", Err: &MissingFileError{Name: "b/two.txt"}},
+	}
+
+	var buf bytes.Buffer
+
+	if err := WriteJUnit(&buf, nil, failures); err != nil {
+		t.Fatal(err)
+	}
+
+	var report junitSuites
+
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Tests != 2 || report.Failures != 1 || report.Errors != 1 {
+		t.Fatalf("unexpected report: %#v", report)
+	}
+
+	cases := report.Suites[0].Cases
+
+	if cases[0].Error == nil || cases[0].Error.Message != "diff failed (exit 2): boom" {
+		t.Fatalf("unexpected test case: %#v", cases[0])
+	}
+
+	if cases[1].Failure == nil || cases[1].Failure.Type != "missing" {
+		t.Fatalf("unexpected test case: %#v", cases[1])
+	}
+}
@@ -0,0 +1,391 @@
+package similardiff
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// LanguageServer reports the differences between the documents open in an
+// editor and their counterpart files as diagnostics, using the Language
+// Server Protocol over a pair of streams. The counterpart of a document is
+// found with the "counterpart" setting of the configuration file, which is
+// read from the root of the workspace.
+type LanguageServer struct {
+	ConfigName string
+
+	config Config
+	root   string
+	texts  map[string]string
+	out    io.Writer
+}
+
+// lspMessage is a JSON-RPC request, response or notification.
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspRelated struct {
+	Location lspLocation `json:"location"`
+	Message  string      `json:"message"`
+}
+
+type lspDiagnostic struct {
+	Range              lspRange     `json:"range"`
+	Severity           int          `json:"severity"`
+	Source             string       `json:"source"`
+	Message            string       `json:"message"`
+	RelatedInformation []lspRelated `json:"relatedInformation,omitempty"`
+}
+
+type lspDocument struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// lspWarning is the severity of the diagnostics.
+const lspWarning = 2
+
+// NewLanguageServer creates a language server that reads its settings from
+// "similardiff.ini".
+func NewLanguageServer() *LanguageServer {
+	return &LanguageServer{ConfigName: "similardiff.ini"}
+}
+
+// Serve reads messages from r and writes the responses into w until the
+// client asks the server to exit or closes the stream.
+func (s *LanguageServer) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	s.texts = map[string]string{}
+
+	reader := bufio.NewReader(r)
+
+	for {
+		msg, err := readMessage(reader)
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *LanguageServer) handle(msg lspMessage) error {
+	var doc lspDocument
+
+	if len(msg.Params) > 0 {
+		_ = json.Unmarshal(msg.Params, &doc) /* only documents are used */
+	}
+
+	uri := doc.TextDocument.URI
+
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(msg)
+	case "shutdown":
+		return s.write(lspMessage{ID: msg.ID, Result: json.RawMessage("null")})
+	case "textDocument/didOpen":
+		s.texts[uri] = doc.TextDocument.Text
+	case "textDocument/didChange":
+		if n := len(doc.ContentChanges); n > 0 {
+			s.texts[uri] = doc.ContentChanges[n-1].Text /* full sync */
+		}
+	case "textDocument/didSave":
+		/* the counterpart may have changed as well */
+	case "textDocument/didClose":
+		delete(s.texts, uri)
+		return s.publish(uri, []lspDiagnostic{})
+	default:
+		if msg.ID != nil {
+			return s.write(lspMessage{ID: msg.ID, Error: &lspError{Code: -32601, Message: "method not found: " + msg.Method}})
+		}
+
+		return nil /* unknown notification */
+	}
+
+	if _, ok := s.texts[uri]; !ok {
+		return nil
+	}
+
+	return s.publish(uri, s.diagnose(uri))
+}
+
+func (s *LanguageServer) initialize(msg lspMessage) error {
+	var params struct {
+		RootURI string `json:"rootUri"`
+	}
+
+	_ = json.Unmarshal(msg.Params, &params)
+
+	if s.root = uriPath(params.RootURI); s.root == "" {
+		s.root, _ = os.Getwd()
+	}
+
+	config, err := LoadConfig(filepath.Join(s.root, s.ConfigName))
+
+	if err != nil {
+		return s.write(lspMessage{ID: msg.ID, Error: &lspError{Code: -32603, Message: err.Error()}})
+	}
+
+	s.config = config
+
+	return s.write(lspMessage{ID: msg.ID, Result: map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{"openClose": true, "change": 1, "save": true},
+		},
+		"serverInfo": map[string]string{"name": "similardiff"},
+	}})
+}
+
+// diagnose compares the text of the document with its counterpart. Problems
+// with the comparison are reported as a diagnostic on the first line.
+func (s *LanguageServer) diagnose(uri string) []lspDiagnostic {
+	name := uriPath(uri)
+	counterpart := CounterpartOf(name, s.config.Counterpart)
+
+	if counterpart == "" {
+		return []lspDiagnostic{}
+	}
+
+	if _, err := os.Stat(counterpart); err != nil {
+		return []lspDiagnostic{}
+	}
+
+	result, err := s.compare(name, s.texts[uri], counterpart)
+
+	if err != nil {
+		return []lspDiagnostic{{
+			Severity: lspWarning,
+			Source:   "similardiff",
+			Message:  err.Error(),
+		}}
+	}
+
+	return pairDiagnostics(result.Pairs, fileURI(counterpart))
+}
+
+// compare saves the text of the document, which may not be saved yet, into
+// a temporary file with the same extension, so the language of the document
+// is detected, and compares it with the counterpart. Documents are always
+// compared line by line, because diagnostics are located by line, and the
+// tolerances are selected by the name of the document, not the copy.
+func (s *LanguageServer) compare(name string, text string, counterpart string) (Result, error) {
+	file, err := os.CreateTemp("", "similardiff-*"+filepath.Ext(name))
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	defer os.Remove(file.Name())
+
+	_, err = io.WriteString(file, text)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	opts := Options{Changes: s.config.Changes, Tolerances: s.config.Tolerances}
+
+	return CompareFiles(file.Name(), counterpart, Options{Changes: opts.rulesFor(name)})
+}
+
+// pairDiagnostics converts the pairs into diagnostics on the lines of file
+// A; lines that only exist in file B are reported on the preceding line.
+func pairDiagnostics(pairs []SimilarDiffPair, counterpart string) []lspDiagnostic {
+	diagnostics := []lspDiagnostic{}
+
+	/* number of added minus deleted lines seen so far */
+	var delta int
+
+	for _, pair := range pairs {
+		var d lspDiagnostic
+
+		d.Severity = lspWarning
+		d.Source = "similardiff"
+
+		switch pair.Group {
+		case Added:
+			anchor := pair.RightLine - delta - 1
+			delta++
+			d.Range = lineRange(anchor-1, "")
+			d.Message = fmt.Sprintf("missing line %d of the counterpart: %s", pair.RightLine, pair.Right)
+		case Deleted:
+			delta--
+			d.Range = lineRange(pair.LeftLine-1, pair.Left)
+			d.Message = "line does not exist in the counterpart"
+		default:
+			d.Range = lineRange(pair.LeftLine-1, pair.Left)
+			d.Message = fmt.Sprintf("differs from line %d of the counterpart: %s", pair.RightLine, pair.Right)
+		}
+
+		if pair.RightLine > 0 {
+			d.RelatedInformation = []lspRelated{{
+				Location: lspLocation{URI: counterpart, Range: lineRange(pair.RightLine-1, pair.Right)},
+				Message:  "counterpart",
+			}}
+		}
+
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics
+}
+
+// lineRange covers the text of a zero-based line; the character offsets of
+// the protocol are counted in UTF-16 code units.
+func lineRange(line int, text string) lspRange {
+	if line < 0 {
+		line = 0
+	}
+
+	return lspRange{
+		Start: lspPosition{Line: line},
+		End:   lspPosition{Line: line, Character: len(utf16.Encode([]rune(text)))},
+	}
+}
+
+func (s *LanguageServer) publish(uri string, diagnostics []lspDiagnostic) error {
+	params, err := json.Marshal(map[string]interface{}{"uri": uri, "diagnostics": diagnostics})
+
+	if err != nil {
+		return err
+	}
+
+	return s.write(lspMessage{Method: "textDocument/publishDiagnostics", Params: params})
+}
+
+func (s *LanguageServer) write(msg lspMessage) error {
+	msg.JSONRPC = "2.0"
+
+	data, err := json.Marshal(msg)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+
+	return err
+}
+
+// readMessage reads one message with its Content-Length header.
+func readMessage(r *bufio.Reader) (lspMessage, error) {
+	var msg lspMessage
+	var length int
+
+	for {
+		line, err := r.ReadString('\n')
+
+		if err != nil {
+			return msg, err
+		}
+
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			break
+		}
+
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return msg, err
+			}
+		}
+	}
+
+	data := make([]byte, length)
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		return msg, err
+	}
+
+	err := json.Unmarshal(data, &msg)
+
+	return msg, err
+}
+
+// CounterpartOf returns the name of the counterpart of a file according to
+// the template, which is relative to the directory of the file. The "{name}"
+// placeholder is the name of the file without its extension. An empty
+// template yields an empty name.
+func CounterpartOf(name string, template string) string {
+	if template == "" {
+		return ""
+	}
+
+	base := filepath.Base(name)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	counterpart := filepath.FromSlash(strings.ReplaceAll(template, "{name}", base))
+
+	if filepath.IsAbs(counterpart) {
+		return counterpart
+	}
+
+	return filepath.Join(filepath.Dir(name), counterpart)
+}
+
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+
+	return filepath.FromSlash(u.Path)
+}
+
+func fileURI(name string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(name)}).String()
+}
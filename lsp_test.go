@@ -0,0 +1,150 @@
+package similardiff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestCounterpartOf(t *testing.T) {
+	name := filepath.Join("src", "go", "parser.go")
+	expected := filepath.Join("src", "c-src", "parser.c")
+
+	if counterpart := CounterpartOf(name, "../c-src/{name}.c"); counterpart != expected {
+		t.Fatalf("expecting %s; got %s", expected, counterpart)
+	}
+
+	if counterpart := CounterpartOf(name, ""); counterpart != "" {
+		t.Fatalf("unexpected counterpart: %s", counterpart)
+	}
+}
+
+func TestLanguageServer(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"similardiff.ini": "import=include\n[lsp]\ncounterpart=../c-src/{name}.c\n",
+		"c-src/main.c":    "include stdio\nint x;\nint y;\n",
+	})
+
+	doc := fileURI(filepath.Join(root, "go", "main.go"))
+
+	var in bytes.Buffer
+
+	send := func(id int, method string, params interface{}) {
+		msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+
+		if id > 0 {
+			msg["id"] = id
+		}
+
+		data, _ := json.Marshal(msg)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	}
+
+	send(1, "initialize", map[string]interface{}{"rootUri": fileURI(root)})
+	send(0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]string{"uri": doc, "text": "import stdio\nint x;\nint z;\n"},
+	})
+	send(0, "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]string{"uri": doc},
+		"contentChanges": []map[string]string{{"text": "import stdio\nint x;\nint y;\n"}},
+	})
+	send(2, "shutdown", nil)
+	send(0, "exit", nil)
+
+	var out bytes.Buffer
+
+	if err := NewLanguageServer().Serve(&in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var published [][]lspDiagnostic
+
+	reader := bufio.NewReader(&out)
+
+	for {
+		msg, err := readMessage(reader)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+
+		var params struct {
+			URI         string          `json:"uri"`
+			Diagnostics []lspDiagnostic `json:"diagnostics"`
+		}
+
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			t.Fatal(err)
+		}
+
+		if params.URI != doc {
+			t.Fatalf("unexpected document: %s", params.URI)
+		}
+
+		published = append(published, params.Diagnostics)
+	}
+
+	if len(published) != 2 {
+		t.Fatalf("expecting diagnostics after open and change; got %d", len(published))
+	}
+
+	/* the first line is similar; the third one is a real difference */
+	if len(published[0]) != 1 || published[0][0].Range.Start.Line != 2 || published[0][0].Range.End.Character != 6 {
+		t.Fatalf("unexpected diagnostics: %#v", published[0])
+	}
+
+	if len(published[1]) != 0 {
+		t.Fatalf("unexpected diagnostics after the change: %#v", published[1])
+	}
+}
+
+func TestLanguageServerCompare(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"similardiff.ini": "[tolerance config.json]\nabsolute = 0.1\n",
+		"other.json":      "{\n  \"ratio\": 1.0,\n  \"name\": \"b\"\n}\n",
+	})
+
+	config, err := LoadConfig(filepath.Join(dir, "similardiff.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewLanguageServer()
+	s.config = config
+
+	text := "{\n  \"ratio\": 1.05,\n  \"name\": \"a\"\n}\n"
+
+	result, err := s.compare(filepath.Join(dir, "config.json"), text, filepath.Join(dir, "other.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* the ratio is within the tolerance of the document */
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "  \"name\": \"a\"", Right: "  \"name\": \"b\"", LeftLine: 3, RightLine: 3},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	if diagnostics := pairDiagnostics(result.Pairs, ""); diagnostics[0].Range.Start.Line != 2 {
+		t.Fatalf("unexpected diagnostics: %#v", diagnostics)
+	}
+}
@@ -0,0 +1,364 @@
+package similardiff
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Expected outcomes of the comparisons of a manifest.
+const (
+	ExpectIdentical = "identical"
+	ExpectDifferent = "different"
+)
+
+// Manifest lists comparisons that are executed together.
+type Manifest struct {
+	Comparisons []ManifestEntry `yaml:"comparisons"`
+}
+
+// ManifestEntry is one comparison of a manifest. A and B are files or
+// directories. Config is the configuration file with the rules of the
+// comparison, Ignore lists the glob patterns of the files that are skipped
+// when comparing directories, and Expect is the expected outcome, either
+// ExpectIdentical, the default, or ExpectDifferent.
+type ManifestEntry struct {
+	Name   string   `yaml:"name"`
+	A      string   `yaml:"a"`
+	B      string   `yaml:"b"`
+	Config string   `yaml:"config"`
+	Ignore []string `yaml:"ignore"`
+	Expect string   `yaml:"expect"`
+}
+
+// ManifestOutcome is the outcome of one comparison of a manifest. OnlyA and
+// OnlyB list the files that exist in only one of the compared directories.
+type ManifestOutcome struct {
+	Entry       ManifestEntry
+	Comparisons []Comparison
+	OnlyA       []string
+	OnlyB       []string
+	Err         error
+}
+
+// LoadManifest reads a manifest in YAML format, or in INI format if the name
+// of the file ends with ".ini". In INI format, every section is a comparison
+// named after the section, and the patterns to ignore are separated by
+// commas:
+//
+//	[parser]
+//	a=go/parser.go
+//	b=c-src/parser.c
+//	config=parser.ini
+//	ignore=*.orig,vendor/*
+//	expect=different
+//
+// Paths are relative to the directory of the manifest.
+func LoadManifest(filename string) (Manifest, error) {
+	var m Manifest
+
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		return m, &ConfigError{Name: filename, Err: err}
+	}
+
+	if strings.EqualFold(filepath.Ext(filename), ".ini") {
+		m, err = parseManifestINI(filename, data)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+
+		if err = decoder.Decode(&m); err != nil {
+			err = &ConfigError{Name: filename, Err: err}
+		}
+	}
+
+	if err != nil {
+		return m, err
+	}
+
+	dir := filepath.Dir(filename)
+
+	for i := range m.Comparisons {
+		entry := &m.Comparisons[i]
+
+		if entry.Expect == "" {
+			entry.Expect = ExpectIdentical
+		}
+
+		if entry.Name == "" {
+			entry.Name = fmt.Sprintf("%s %s", entry.A, entry.B)
+		}
+
+		if entry.A == "" || entry.B == "" {
+			return m, &ConfigError{Name: filename, Err: fmt.Errorf("%s: missing files to compare", entry.Name)}
+		}
+
+		if entry.Expect != ExpectIdentical && entry.Expect != ExpectDifferent {
+			return m, &ConfigError{Name: filename, Err: fmt.Errorf("%s: unknown outcome %s", entry.Name, entry.Expect)}
+		}
+
+		entry.A = relativeTo(dir, entry.A)
+		entry.B = relativeTo(dir, entry.B)
+
+		if entry.Config != "" {
+			entry.Config = relativeTo(dir, entry.Config)
+		}
+	}
+
+	return m, nil
+}
+
+func parseManifestINI(filename string, data []byte) (Manifest, error) {
+	var m Manifest
+	var entry *ManifestEntry
+	var number int
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+
+		/* skip empty lines and comments */
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			m.Comparisons = append(m.Comparisons, ManifestEntry{Name: strings.TrimSpace(line[1 : len(line)-1])})
+			entry = &m.Comparisons[len(m.Comparisons)-1]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+
+		if !ok || entry == nil {
+			return m, &ConfigError{Name: filename, Line: number, Err: ErrMalformedRule}
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "a":
+			entry.A = value
+		case "b":
+			entry.B = value
+		case "config":
+			entry.Config = value
+		case "ignore":
+			for _, pattern := range strings.Split(value, ",") {
+				if pattern = strings.TrimSpace(pattern); pattern != "" {
+					entry.Ignore = append(entry.Ignore, pattern)
+				}
+			}
+		case "expect":
+			entry.Expect = value
+		default:
+			return m, &ConfigError{Name: filename, Line: number, Err: fmt.Errorf("unknown setting %s", key)}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return m, &ConfigError{Name: filename, Err: err}
+	}
+
+	return m, nil
+}
+
+func relativeTo(dir string, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(dir, name)
+}
+
+// Run executes every comparison of the manifest, in order. The options apply
+// to all of them, except the rules, which come from the configuration file
+// of every comparison. Directories are compared by a pool of workers.
+func (m Manifest) Run(opts Options, workers int) []ManifestOutcome {
+	outcomes := make([]ManifestOutcome, len(m.Comparisons))
+
+	for i, entry := range m.Comparisons {
+		outcomes[i] = runEntry(entry, opts, workers)
+	}
+
+	return outcomes
+}
+
+func runEntry(entry ManifestEntry, opts Options, workers int) ManifestOutcome {
+	outcome := ManifestOutcome{Entry: entry}
+
+	var config Config
+
+	/* unlike the default configuration, a named one must exist */
+	if entry.Config != "" {
+		if err := checkFiles(entry.Config); err != nil {
+			outcome.Err = err
+			return outcome
+		}
+
+		c, err := LoadConfig(entry.Config)
+
+		if err != nil {
+			outcome.Err = err
+			return outcome
+		}
+
+		config = c
+	}
+
+	opts.Changes = config.Changes
+	opts.Columns = config.Columns
+	opts.Tolerances = config.Tolerances
+	opts.ByteChanges = config.Bytes
+
+	infoA, errA := os.Stat(entry.A)
+	infoB, errB := os.Stat(entry.B)
+
+	if errA != nil || errB != nil || !infoA.IsDir() || !infoB.IsDir() {
+		if errA == nil && errB == nil && infoA.IsDir() != infoB.IsDir() {
+			outcome.Err = errors.New("cannot compare a file with a directory")
+			return outcome
+		}
+
+		result, err := CompareFiles(entry.A, entry.B, opts)
+
+		result.FileA = entry.A
+		result.FileB = entry.B
+
+		outcome.Comparisons = []Comparison{{Result: result, Err: err}}
+
+		return outcome
+	}
+
+	dirs, err := PairDirectories(entry.A, entry.B)
+
+	if err != nil {
+		outcome.Err = err
+		return outcome
+	}
+
+	var pairs []FilePair
+
+	for _, pair := range dirs.Pairs {
+		if name, _ := filepath.Rel(entry.A, pair.FileA); !matchesAny(name, entry.Ignore) {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	for _, name := range dirs.OnlyA {
+		if !matchesAny(name, entry.Ignore) {
+			outcome.OnlyA = append(outcome.OnlyA, name)
+		}
+	}
+
+	for _, name := range dirs.OnlyB {
+		if !matchesAny(name, entry.Ignore) {
+			outcome.OnlyB = append(outcome.OnlyB, name)
+		}
+	}
+
+	outcome.Comparisons = CompareBatch(pairs, opts, workers)
+
+	return outcome
+}
+
+// matchesAny reports whether the relative path, any of its leading
+// directories, or its base name, matches one of the patterns. So "vendor/*"
+// matches "vendor/a/b.go" because it matches the directory "vendor/a".
+func matchesAny(name string, patterns []string) bool {
+	name = filepath.ToSlash(name)
+	base := path.Base(name)
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+
+		for prefix := name; prefix != "." && prefix != "/"; prefix = path.Dir(prefix) {
+			if ok, _ := path.Match(pattern, prefix); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Failed returns the first error of the outcome, if any.
+func (o ManifestOutcome) Failed() error {
+	if o.Err != nil {
+		return o.Err
+	}
+
+	for _, c := range o.Comparisons {
+		if c.Err != nil {
+			return c.Err
+		}
+	}
+
+	return nil
+}
+
+// Failures returns the files that could not be compared, including the ones
+// that exist in only one of the directories; if the comparison itself could
+// not run, the files of the entry are reported instead.
+func (o ManifestOutcome) Failures() []Failure {
+	if o.Err != nil {
+		return []Failure{{FileA: o.Entry.A, FileB: o.Entry.B, Err: o.Err}}
+	}
+
+	dirs := DirectoryPairs{OnlyA: o.OnlyA, OnlyB: o.OnlyB}
+
+	return dirs.Failures(o.Entry.A, o.Entry.B, o.Comparisons)
+}
+
+// Differences returns the number of differences found, including the files
+// that exist in only one of the directories.
+func (o ManifestOutcome) Differences() int {
+	n := len(o.OnlyA) + len(o.OnlyB)
+
+	for _, c := range o.Comparisons {
+		n += len(c.Pairs)
+	}
+
+	return n
+}
+
+// Passed reports whether the comparison succeeded with the expected outcome.
+func (o ManifestOutcome) Passed() bool {
+	if o.Failed() != nil {
+		return false
+	}
+
+	return (o.Differences() > 0) == (o.Entry.Expect == ExpectDifferent)
+}
+
+// ManifestStatus aggregates the outcomes following the convention of the
+// diff tool; 0 means every comparison had the expected outcome, 1 means that
+// at least one of them did not, and 2 means that at least one failed.
+func ManifestStatus(outcomes []ManifestOutcome) int {
+	status := 0
+
+	for _, o := range outcomes {
+		if o.Failed() != nil {
+			return 2
+		}
+
+		if !o.Passed() {
+			status = 1
+		}
+	}
+
+	return status
+}
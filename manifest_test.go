@@ -0,0 +1,111 @@
+package similardiff
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"rules.ini":     "foo=bar\n",
+		"a/one.txt":     "foo 1\n",
+		"b/one.txt":     "bar 1\n",
+		"a/two.txt":     "x\n",
+		"b/two.txt":     "y\n",
+		"a/skip.orig":   "only in a\n",
+		"manifest.yaml": "comparisons:\n  - name: dirs\n    a: a\n    b: b\n    config: rules.ini\n    ignore: [two.txt, '*.orig']\n  - a: a/two.txt\n    b: b/two.txt\n    expect: different\n  - name: missing\n    a: a/one.txt\n    b: b/one.txt\n",
+		"manifest.ini":  "[dirs]\na=a\nb=b\nconfig=rules.ini\nignore=two.txt, *.orig\n",
+		"broken.yaml":   "comparisons:\n  - a: a\n    b: b\n    expect: maybe\n",
+		"unknown.yaml":  "comparisons:\n  - a: a\n    b: b\n    rules: x\n",
+		"missing.ini":   "[dirs]\na=a\nb=b\nconfig=nowhere.ini\n",
+	})
+
+	m, err := LoadManifest(filepath.Join(dir, "manifest.yaml"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Comparisons) != 3 || m.Comparisons[1].Name != "a/two.txt b/two.txt" || m.Comparisons[0].Expect != ExpectIdentical {
+		t.Fatalf("unexpected manifest: %#v", m)
+	}
+
+	outcomes := m.Run(Options{}, 2)
+
+	/* the third comparison has no rules, so the files differ */
+	for i, passed := range []bool{true, true, false} {
+		if outcomes[i].Passed() != passed {
+			t.Fatalf("%s: expecting passed=%v; got %#v", outcomes[i].Entry.Name, passed, outcomes[i])
+		}
+	}
+
+	if len(outcomes[0].Comparisons) != 1 || len(outcomes[0].OnlyA) != 0 {
+		t.Fatalf("ignored files were compared: %#v", outcomes[0])
+	}
+
+	if status := ManifestStatus(outcomes); status != 1 {
+		t.Fatalf("unexpected exit status: %d", status)
+	}
+
+	m, err = LoadManifest(filepath.Join(dir, "manifest.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if outcomes := m.Run(Options{}, 1); len(outcomes) != 1 || !outcomes[0].Passed() || ManifestStatus(outcomes) != 0 {
+		t.Fatalf("unexpected outcomes: %#v", outcomes)
+	}
+
+	for _, name := range []string{"broken.yaml", "unknown.yaml"} {
+		var configErr *ConfigError
+
+		if _, err := LoadManifest(filepath.Join(dir, name)); !errors.As(err, &configErr) {
+			t.Fatalf("%s: expecting ConfigError; got %#v", name, err)
+		}
+	}
+
+	m, err = LoadManifest(filepath.Join(dir, "missing.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var missing *MissingFileError
+
+	outcomes = m.Run(Options{}, 1)
+
+	if !errors.As(outcomes[0].Failed(), &missing) || ManifestStatus(outcomes) != 2 {
+		t.Fatalf("expecting a missing configuration; got %#v", outcomes[0])
+	}
+
+	if failures := outcomes[0].Failures(); len(failures) != 1 || failures[0].FileA != outcomes[0].Entry.A {
+		t.Fatalf("unexpected failures: %#v", failures)
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches bool
+	}{
+		{"vendor/a.go", true},
+		{"vendor/a/b.go", true},
+		{"src/vendor/a.go", false},
+		{"src/main.orig", true},
+		{"src/build/x/y.txt", false},
+		{"build/x/y.txt", true},
+		{"main.go", false},
+	}
+
+	patterns := []string{"vendor/*", "*.orig", "build"}
+
+	for _, test := range tests {
+		if matchesAny(filepath.FromSlash(test.name), patterns) != test.matches {
+			t.Fatalf("%s: expecting %v", test.name, test.matches)
+		}
+	}
+}
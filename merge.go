@@ -0,0 +1,152 @@
+package similardiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Merge writes a file that combines file A and file B. Lines that are equal
+// in both files, and lines that only differ in ways explained by the
+// similarity rules, are taken from file B. Every other difference is written
+// between conflict markers. It returns the number of conflicts. The merged
+// file is written in UTF-8 with LF line endings, whatever the encoding of
+// the files.
+//
+// Binary files, and the structural and tabular comparisons, cannot be merged
+// because their differences are not located by line; ErrNotLineBased is
+// returned instead.
+func Merge(w io.Writer, fileA string, fileB string, opts Options) (int, error) {
+	opts.Moves = false /* moved lines are merged where they are in file B */
+
+	result, err := CompareFiles(fileA, fileB, opts)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !result.LineBased() {
+		return 0, ErrNotLineBased
+	}
+
+	linesB, err := readLines(fileB)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var ours []string
+	var theirs []string
+	var conflicts int
+
+	out := bufio.NewWriter(w)
+
+	/* next line to consume from each file; 1-based */
+	i, j := 1, 1
+
+	flush := func() {
+		if len(ours) == 0 && len(theirs) == 0 {
+			return
+		}
+
+		fmt.Fprintf(out, "<<<<<<< %s\n", fileA)
+
+		for _, line := range ours {
+			fmt.Fprintln(out, line)
+		}
+
+		fmt.Fprintln(out, "=======")
+
+		for _, line := range theirs {
+			fmt.Fprintln(out, line)
+		}
+
+		fmt.Fprintf(out, ">>>>>>> %s\n", fileB)
+
+		ours, theirs = nil, nil
+		conflicts++
+	}
+
+	/* unchanged lines exist in both files; copy the ones in file B */
+	copyUntil := func(left int, right int) {
+		for (left > 0 && i < left) || (right > 0 && j < right) {
+			flush()
+			fmt.Fprintln(out, linesB[j-1])
+			i++
+			j++
+		}
+	}
+
+	for _, item := range result.Ordered() {
+		pair := item.Pair
+
+		copyUntil(pair.LeftLine, pair.RightLine)
+
+		if item.Discarded {
+			/* similar lines; keep the version in file B */
+			flush()
+			fmt.Fprintln(out, pair.Right)
+			i++
+			j++
+			continue
+		}
+
+		if pair.LeftLine > 0 {
+			ours = append(ours, pair.Left)
+			i++
+		}
+
+		if pair.RightLine > 0 {
+			theirs = append(theirs, pair.Right)
+			j++
+		}
+	}
+
+	flush()
+
+	for ; j <= len(linesB); j++ {
+		fmt.Fprintln(out, linesB[j-1])
+	}
+
+	return conflicts, out.Flush()
+}
+
+// readLines returns the lines of a file without the line terminators, in
+// UTF-8 with the line endings normalized like the compared copies are.
+func readLines(filename string) ([]string, error) {
+	enc, err := DetectEncoding(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var decoded bytes.Buffer
+	var normalized bytes.Buffer
+
+	if err := decodeText(&decoded, file, enc.Charset); err != nil {
+		return nil, err
+	}
+
+	if err := normalizeLineEndings(&normalized, &decoded); err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimSuffix(normalized.String(), "\n")
+
+	if text == "" {
+		return nil, nil
+	}
+
+	return strings.Split(text, "\n"), nil
+}
@@ -0,0 +1,123 @@
+package similardiff
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "import a\nsame\nlorem\ndeleted\nsame\nimport b\n",
+		"b.txt": "include a\nsame\nipsum\nsame\ninclude b\nadded\n",
+	})
+
+	fileA := filepath.Join(dir, "a.txt")
+	fileB := filepath.Join(dir, "b.txt")
+
+	opts := Options{Changes: []SimilarDiffChange{{Old: "import", New: "include"}}}
+
+	var buf bytes.Buffer
+
+	conflicts, err := Merge(&buf, fileA, fileB, opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "include a\n" +
+		"same\n" +
+		"<<<<<<< " + fileA + "\n" +
+		"lorem\n" +
+		"deleted\n" +
+		"=======\n" +
+		"ipsum\n" +
+		">>>>>>> " + fileB + "\n" +
+		"same\n" +
+		"include b\n" +
+		"<<<<<<< " + fileA + "\n" +
+		"=======\n" +
+		"added\n" +
+		">>>>>>> " + fileB + "\n"
+
+	if buf.String() != expected {
+		t.Fatalf("unexpected merge:\n%s", buf.String())
+	}
+
+	if conflicts != 2 {
+		t.Fatalf("expecting two conflicts; got %d", conflicts)
+	}
+}
+
+func TestMergeBinary(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.bin":  "\x00\x01\x02",
+		"b.bin":  "\x00\x01\x03",
+		"a.json": `{"name": "a"}`,
+		"b.json": `{"name": "b"}`,
+	})
+
+	var buf bytes.Buffer
+
+	for _, opts := range []Options{{}, {ByteLevel: true}} {
+		if _, err := Merge(&buf, filepath.Join(dir, "a.bin"), filepath.Join(dir, "b.bin"), opts); !errors.Is(err, ErrNotLineBased) {
+			t.Fatalf("expecting ErrNotLineBased; got %v", err)
+		}
+	}
+
+	if _, err := Merge(&buf, filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), Options{Structural: true}); !errors.Is(err, ErrNotLineBased) {
+		t.Fatalf("expecting ErrNotLineBased; got %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestMergeEncodings(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "import a\nsame\nlorem\n",
+		"b.txt": "include a\r\nsame\r\nipsum\r\n",
+		"c.txt": "\xff\xfei\x00n\x00c\x00l\x00u\x00d\x00e\x00 \x00a\x00\n\x00s\x00a\x00m\x00e\x00\n\x00\xe9\x00\n\x00",
+	})
+
+	fileA := filepath.Join(dir, "a.txt")
+	opts := Options{Changes: []SimilarDiffChange{{Old: "import", New: "include"}}}
+
+	tests := []struct {
+		fileB string
+		last  string
+	}{
+		{"b.txt", "ipsum"},
+		{"c.txt", "\u00e9"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+
+		fileB := filepath.Join(dir, test.fileB)
+
+		if _, err := Merge(&buf, fileA, fileB, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "include a\n" +
+			"same\n" +
+			"<<<<<<< " + fileA + "\n" +
+			"lorem\n" +
+			"=======\n" +
+			test.last + "\n" +
+			">>>>>>> " + fileB + "\n"
+
+		if buf.String() != expected {
+			t.Fatalf("unexpected merge of %s:\n%q", test.fileB, buf.String())
+		}
+	}
+}
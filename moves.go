@@ -0,0 +1,161 @@
+package similardiff
+
+import (
+	"strings"
+)
+
+// Moved marks a block of lines that was deleted from one place of file A and
+// added to another place of file B. Left and Right hold the lines of the
+// block separated by newlines, and LeftLine and RightLine are the first line
+// of the block in each file.
+const Moved rune = 'm'
+
+// movedMinimum is the smallest block considered a move; single lines, like
+// a closing brace, are often deleted and added elsewhere by coincidence.
+const movedMinimum = 2
+
+// DetectMoves replaces the blocks of deleted lines that were added somewhere
+// else with one pair of the Moved group. Deleted lines are compared with the
+// added lines once the similarity rules are applied to them.
+func (s *SimilarDiff) DetectMoves() {
+	var deleted []int
+	var added []int
+
+	normalized := map[int]string{}
+
+	/* positions in added of the lines with the same text; lines without
+	 * words, like a closing brace, repeat too often to start a block */
+	index := map[string][]int{}
+
+	for i, pair := range s.Pairs {
+		switch pair.Group {
+		case Deleted:
+			deleted = append(deleted, i)
+			normalized[i], _ = applyChangesIn(pair.Left, s.Changes, s.Language)
+		case Added:
+			if hasWords([]string{pair.Right}) {
+				index[pair.Right] = append(index[pair.Right], len(added))
+			}
+			added = append(added, i)
+		}
+	}
+
+	used := make([]bool, len(s.Pairs))
+	moved := map[int]SimilarDiffPair{}
+
+	for d := range deleted {
+		if used[deleted[d]] {
+			continue
+		}
+
+		best, start := 0, 0
+
+		/* only the added lines equal to the first line can start a block */
+		for _, a := range index[normalized[deleted[d]]] {
+			if used[added[a]] {
+				continue
+			}
+
+			if n := s.movedLength(deleted[d:], added[a:], normalized, used); n > best {
+				best, start = n, a
+			}
+
+			if best == len(deleted)-d {
+				break /* no other block can be longer */
+			}
+		}
+
+		if best == 0 {
+			continue
+		}
+
+		/* lines without words can still be the beginning of a block */
+		first := d
+
+		for first > 0 && start > 0 && s.movedMatch(deleted[first-1], added[start-1], normalized, used) &&
+			s.adjacent(deleted[first-1], added[start-1], deleted[first], added[start]) {
+			first, start, best = first-1, start-1, best+1
+		}
+
+		if best < movedMinimum {
+			continue
+		}
+
+		var left, right []string
+
+		for k := 0; k < best; k++ {
+			left = append(left, s.Pairs[deleted[first+k]].Left)
+			right = append(right, s.Pairs[added[start+k]].Right)
+			used[deleted[first+k]] = true
+			used[added[start+k]] = true
+		}
+
+		moved[deleted[first]] = SimilarDiffPair{
+			Group:     Moved,
+			Left:      strings.Join(left, "\n"),
+			Right:     strings.Join(right, "\n"),
+			LeftLine:  s.Pairs[deleted[first]].LeftLine,
+			RightLine: s.Pairs[added[start]].RightLine,
+		}
+	}
+
+	pairs := make([]SimilarDiffPair, 0, len(s.Pairs))
+
+	for i, pair := range s.Pairs {
+		if block, ok := moved[i]; ok {
+			pairs = append(pairs, block)
+			continue
+		}
+
+		if !used[i] {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	s.Pairs = pairs
+}
+
+// movedLength returns the number of consecutive deleted lines, from the
+// beginning of the list, that match consecutive added lines.
+func (s *SimilarDiff) movedLength(deleted []int, added []int, normalized map[int]string, used []bool) int {
+	var n int
+
+	for n < len(deleted) && n < len(added) && s.movedMatch(deleted[n], added[n], normalized, used) {
+		if n > 0 && !s.adjacent(deleted[n-1], added[n-1], deleted[n], added[n]) {
+			break
+		}
+
+		n++
+	}
+
+	return n
+}
+
+// movedMatch reports whether the deleted line d, once normalized, is equal to
+// the added line a, and neither belongs to a block yet.
+func (s *SimilarDiff) movedMatch(d int, a int, normalized map[int]string, used []bool) bool {
+	return !used[d] && !used[a] && normalized[d] == s.Pairs[a].Right
+}
+
+// adjacent reports whether the lines d and a follow the lines prevD and prevA
+// in their files, so the four of them can belong to the same block.
+func (s *SimilarDiff) adjacent(prevD int, prevA int, d int, a int) bool {
+	return s.Pairs[d].LeftLine == s.Pairs[prevD].LeftLine+1 && s.Pairs[a].RightLine == s.Pairs[prevA].RightLine+1
+}
+
+// hasWords reports whether any of the lines has a word character; blocks of
+// blank lines and punctuation are not reported as moved.
+func hasWords(lines []string) bool {
+	for _, line := range lines {
+		if strings.IndexFunc(line, isWord) >= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MovedLines returns the number of lines of a pair of the Moved group.
+func MovedLines(pair SimilarDiffPair) int {
+	return strings.Count(pair.Left, "\n") + 1
+}
@@ -0,0 +1,120 @@
+package similardiff
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectMoves(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.go": "package main\nfunc a() {\n\treturn x\n}\nfunc b() {\n\tc()\n\td()\n\treturn\n}\n}\n",
+		"b.go": "package main\nfunc b() {\n\tc()\n\td()\n\treturn\n}\nfunc a() {\n\treturn y\n}\n",
+	})
+
+	fileA := filepath.Join(dir, "a.go")
+	fileB := filepath.Join(dir, "b.go")
+	opts := Options{Changes: []SimilarDiffChange{{Old: "x", New: "y", Kind: KindIdentifier}}, Moves: true}
+
+	result, err := CompareFiles(fileA, fileB, opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'm', Left: "func a() {\n\treturn x", Right: "func a() {\n\treturn y", LeftLine: 2, RightLine: 7},
+		{Group: 'd', Left: "}", LeftLine: 4},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 2, expected)
+
+	if MovedLines(result.Pairs[0]) != 2 {
+		t.Fatalf("unexpected block size: %d", MovedLines(result.Pairs[0]))
+	}
+
+	/* without the option, the block is reported line by line */
+	opts.Moves = false
+
+	if result, err = CompareFiles(fileA, fileB, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pair := range result.Pairs {
+		if pair.Group == Moved {
+			t.Fatalf("unexpected moved block: %#v", pair)
+		}
+	}
+}
+
+func TestDetectMovesLongestBlock(t *testing.T) {
+	s := NewSimilarDiff()
+
+	s.Pairs = []SimilarDiffPair{
+		{Group: Deleted, Left: "alpha", LeftLine: 1},
+		{Group: Deleted, Left: "beta", LeftLine: 2},
+		{Group: Deleted, Left: "gamma", LeftLine: 3},
+		{Group: Added, Right: "alpha", RightLine: 10},
+		{Group: Added, Right: "alpha", RightLine: 20},
+		{Group: Added, Right: "beta", RightLine: 21},
+		{Group: Added, Right: "gamma", RightLine: 22},
+	}
+
+	s.DetectMoves()
+
+	/* the block starts at the second copy of the first line */
+	expected := []SimilarDiffPair{
+		{Group: 'm', Left: "alpha\nbeta\ngamma", Right: "alpha\nbeta\ngamma", LeftLine: 1, RightLine: 20},
+		{Group: 'a', Right: "alpha", RightLine: 10},
+	}
+
+	CheckTestData(t, s, 2, expected)
+}
+
+func TestDetectMovesRepeatedLines(t *testing.T) {
+	dir := t.TempDir()
+
+	var body, braces, returns string
+
+	for i := 0; i < 4000; i++ {
+		body += fmt.Sprintf("line %d\n", i)
+	}
+
+	for i := 0; i < 1500; i++ {
+		braces += "}\n"
+		returns += "return nil\n"
+	}
+
+	/* the body is longer, so diff keeps it and moves the repeated lines */
+	writeFiles(t, dir, map[string]string{
+		"a.txt": braces + returns + body,
+		"b.txt": body + braces + returns,
+	})
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{Moves: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* the braces begin the block because the lines after them have words */
+	if len(result.Pairs) != 1 || result.Pairs[0].Group != Moved || MovedLines(result.Pairs[0]) != 3000 {
+		t.Fatalf("expecting one moved block of 3000 lines; got %#v", result.Pairs[0])
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": braces + body,
+		"b.txt": body + braces,
+	})
+
+	if result, err = CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{Moves: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	/* blocks without words are reported line by line */
+	if len(result.Pairs) != 3000 {
+		t.Fatalf("expecting 3000 pairs; got %d", len(result.Pairs))
+	}
+}
@@ -0,0 +1,133 @@
+package similardiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// KindPattern marks a rule whose Old field is a template with placeholders,
+// for example "build {semver} at {date}". The text that matches the template
+// is replaced with the template itself in both lines before comparing them,
+// so any two lines that only differ in the placeholders are similar.
+const KindPattern = "pattern"
+
+// placeholders maps the names accepted by CompilePattern to the expressions
+// they match.
+var placeholders = map[string]string{
+	"num":    `[-+]?[0-9]+(?:\.[0-9]+)?(?:[eE][-+]?[0-9]+)?`,
+	"hex":    `(?:0[xX])?[0-9a-fA-F]+`,
+	"uuid":   `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"ipv4":   `(?:[0-9]{1,3}\.){3}[0-9]{1,3}`,
+	"date":   `[0-9]{4}-[0-9]{2}-[0-9]{2}(?:[T ][0-9]{2}:[0-9]{2}(?::[0-9]{2}(?:\.[0-9]+)?)?(?:Z|[-+][0-9]{2}:?[0-9]{2})?)?`,
+	"semver": `v?[0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`,
+	"path":   `(?:[A-Za-z]:)?[\w.~-]*(?:[/\\][\w.~-]+)+[/\\]?`,
+}
+
+var placeholder = regexp.MustCompile(`\{([a-z0-9]+)\}`)
+
+// patternCacheSize is the number of compiled templates kept in memory. The
+// server compiles the templates sent by its clients, so the cache is
+// emptied once it is full instead of growing without bounds.
+const patternCacheSize = 1024
+
+/* compiled templates, shared by concurrent comparisons */
+var patterns = struct {
+	sync.Mutex
+	cache map[string]*regexp.Regexp
+}{cache: map[string]*regexp.Regexp{}}
+
+// CompilePattern converts a template into a regular expression. Text outside
+// the placeholders {num}, {hex}, {uuid}, {ipv4}, {date}, {semver} and {path}
+// matches literally.
+func CompilePattern(template string) (*regexp.Regexp, error) {
+	patterns.Lock()
+	re, ok := patterns.cache[template]
+	patterns.Unlock()
+
+	if ok {
+		return re, nil
+	}
+
+	var expr strings.Builder
+	var last int
+
+	for _, m := range placeholder.FindAllStringSubmatchIndex(template, -1) {
+		name := template[m[2]:m[3]]
+		value, ok := placeholders[name]
+
+		if !ok {
+			return nil, fmt.Errorf("unknown placeholder {%s}", name)
+		}
+
+		expr.WriteString(regexp.QuoteMeta(template[last:m[0]]))
+		expr.WriteString("(?:" + value + ")")
+		last = m[1]
+	}
+
+	if last == 0 {
+		return nil, fmt.Errorf("template %q has no placeholders", template)
+	}
+
+	expr.WriteString(regexp.QuoteMeta(template[last:]))
+
+	re, err := regexp.Compile(expr.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	patterns.Lock()
+
+	if len(patterns.cache) >= patternCacheSize {
+		patterns.cache = map[string]*regexp.Regexp{}
+	}
+
+	patterns.cache[template] = re
+	patterns.Unlock()
+
+	return re, nil
+}
+
+// similarLines applies the rules to the lines and reports whether they
+// become equal, along with the rules that modified them. Replacements apply
+// to the left line, pattern rules to both of them, and finally the numbers
+// of both lines are compared with the tolerance rules.
+func similarLines(left string, right string, changes []SimilarDiffChange, lang string) (bool, []SimilarDiffChange) {
+	temp, rules := applyChangesIn(left, changes, lang)
+
+	if temp == right {
+		return true, rules
+	}
+
+	for _, change := range changes {
+		if change.Kind != KindPattern {
+			continue
+		}
+
+		re, err := CompilePattern(change.Old)
+
+		if err != nil || !re.MatchString(temp) || !re.MatchString(right) {
+			continue
+		}
+
+		temp = re.ReplaceAllLiteralString(temp, change.Old)
+		right = re.ReplaceAllLiteralString(right, change.Old)
+		rules = append(rules, change)
+
+		if temp == right {
+			return true, rules
+		}
+	}
+
+	if !hasTolerances(changes) {
+		return false, rules
+	}
+
+	if ok, tolerances := withinTolerance(temp, right, changes); ok {
+		return true, append(rules, tolerances...)
+	}
+
+	return false, rules
+}
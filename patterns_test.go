@@ -0,0 +1,114 @@
+package similardiff
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		template string
+		line     string
+		match    bool
+	}{
+		{"build {semver} at {date}", "build v1.2.3-rc.1 at 2024-01-31T10:00:00Z", true},
+		{"build {semver} at {date}", "build 1.2 at 2024-01-31", false},
+		{"id={uuid}", "id=123e4567-e89b-12d3-a456-426614174000", true},
+		{"from {ipv4}:{num}", "from 10.0.0.1:8080", true},
+		{"at {hex} in {path}", "at 0xdeadbeef in /usr/lib/libc.so", true},
+		{"took {num}s", "took 1.5e-3s", true},
+		{"took {num}s (x)", "took 1s (y)", false},
+	}
+
+	for _, test := range tests {
+		re, err := CompilePattern(test.template)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if re.MatchString(test.line) != test.match {
+			t.Fatalf("%q with %q: expecting match=%v", test.template, test.line, test.match)
+		}
+	}
+
+	for _, template := range []string{"{size} bytes", "no placeholders"} {
+		if _, err := CompilePattern(template); err == nil {
+			t.Fatalf("expecting an error for %q", template)
+		}
+	}
+}
+
+func TestCompilePatternCache(t *testing.T) {
+	/* templates sent by clients of the server must not accumulate */
+	for i := 0; i < 2*patternCacheSize; i++ {
+		if _, err := CompilePattern(fmt.Sprintf("request %d {num}", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	patterns.Lock()
+	size := len(patterns.cache)
+	patterns.Unlock()
+
+	if size > patternCacheSize {
+		t.Fatalf("expecting at most %d compiled templates; got %d", patternCacheSize, size)
+	}
+}
+
+func TestComparePatternRules(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt":           "package 1\nbuild v1.2.3 at 2024-01-31 ok\nbuild v1.2.3 at 2024-01-31 ok\n",
+		"b.txt":           "module 1\nbuild v1.3.0 at 2024-02-01 ok\nbuild v1.3.0 at 2024-02-01 failed\n",
+		"similardiff.ini": "package=module\n[pattern]\nbuild {semver} at {date}\n",
+		"broken.ini":      "[pattern]\n{size} bytes\n",
+	})
+
+	config, err := LoadConfig(filepath.Join(dir, "similardiff.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{Changes: config.Changes})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "build v1.2.3 at 2024-01-31 ok", Right: "build v1.3.0 at 2024-02-01 failed", LeftLine: 3, RightLine: 3},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	if len(result.Discarded) != 2 || result.Discarded[1].Rules[0].String() != "build {semver} at {date}" {
+		t.Fatalf("unexpected discarded pairs: %#v", result.Discarded)
+	}
+
+	var configErr *ConfigError
+
+	if _, err := LoadConfig(filepath.Join(dir, "broken.ini")); !errors.As(err, &configErr) || configErr.Line != 2 {
+		t.Fatalf("expecting ConfigError on line 2; got %#v", err)
+	}
+
+	/* templates are written without replacement */
+	if err := AppendChange(filepath.Join(dir, "broken.ini"), SimilarDiffChange{Old: "took {num}ms", Kind: KindPattern}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "broken.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "[pattern]\n{size} bytes\n[pattern]\ntook {num}ms\n" {
+		t.Fatalf("unexpected configuration:\n%s", data)
+	}
+}
@@ -0,0 +1,207 @@
+package similardiff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Printer writes the result of a comparison in a unified-like format.
+// Context is the number of unchanged lines printed around the differences.
+type Printer struct {
+	Output   io.Writer
+	Colorize bool
+	Context  int
+}
+
+// NewPrinter creates a printer that writes into w.
+func NewPrinter(w io.Writer) *Printer {
+	return &Printer{Output: w}
+}
+
+func (p *Printer) SetColorize(value string) {
+	p.Colorize = (value == "true")
+}
+
+// SetContext sets the number of unchanged lines printed around every group
+// of differences by PrettyPrint.
+func (p *Printer) SetContext(lines int) {
+	p.Context = lines
+}
+
+// PrettyPrint writes the pairs of the result, if any.
+func (p *Printer) PrettyPrint(r Result) {
+	/* there are no changes */
+	if len(r.Pairs) <= 0 && len(r.Notes) <= 0 {
+		return
+	}
+
+	p.PrintHeader(r.FileA, r.FileB)
+
+	for _, note := range r.Notes {
+		p.PrintCyan("# %s", note)
+	}
+
+	if p.Context > 0 && p.printContext(r) {
+		return
+	}
+
+	for _, group := range r.Pairs {
+		p.PrintPair(group)
+	}
+}
+
+// PrintHeader writes the names of the compared files.
+func (p *Printer) PrintHeader(fileA string, fileB string) {
+	p.PrintRed("--- %s", fileA)
+	p.PrintGreen("+++ %s", fileB)
+}
+
+// PrintPair writes one pair; lines that do not exist in a file are skipped.
+func (p *Printer) PrintPair(group SimilarDiffPair) {
+	/* structured documents are identified by path */
+	if group.Path != "" {
+		if group.Group != Added {
+			p.PrintRed("%s\t-%s", group.Path, group.Left)
+		}
+
+		if group.Group != Deleted {
+			p.PrintGreen("%s\t+%s", group.Path, group.Right)
+		}
+
+		return
+	}
+
+	if group.Group == Moved {
+		lines := MovedLines(group)
+		first, _, _ := strings.Cut(group.Left, "\n")
+		p.PrintCyan("%d-%d\tmoved to %d-%d\t%s", group.LeftLine, group.LeftLine+lines-1, group.RightLine, group.RightLine+lines-1, first)
+		return
+	}
+
+	if group.LeftLine > 0 {
+		p.PrintRed("%d\t-%s", group.LeftLine, group.Left)
+	}
+
+	if group.RightLine > 0 {
+		p.PrintGreen("%d\t+%s", group.RightLine, group.Right)
+	}
+}
+
+// PrintThreeWay writes the changes of a three-way comparison; each change
+// shows the line of the common ancestor, followed by the lines of file A
+// prefixed with "<" and the lines of file B prefixed with ">".
+func (p *Printer) PrintThreeWay(r ThreeWayResult) {
+	/* there are no changes */
+	if len(r.Changes) <= 0 {
+		return
+	}
+
+	p.PrintRed("--- %s", r.Base)
+	p.PrintGreen("<<< %s", r.FileA)
+	p.PrintGreen(">>> %s", r.FileB)
+
+	for _, change := range r.Changes {
+		if change.Insert {
+			p.PrintCyan("%s after %d", threeWayClass[change.Class], change.BaseLine)
+		} else {
+			p.PrintCyan("%s %d", threeWayClass[change.Class], change.BaseLine)
+			p.PrintRed("%d\t-%s", change.BaseLine, change.Base)
+		}
+
+		for _, pair := range change.Ours {
+			if pair.RightLine > 0 {
+				p.PrintGreen("%d\t<%s", pair.RightLine, pair.Right)
+			}
+		}
+
+		for _, pair := range change.Theirs {
+			if pair.RightLine > 0 {
+				p.PrintGreen("%d\t>%s", pair.RightLine, pair.Right)
+			}
+		}
+	}
+}
+
+// PrintStats writes a summary with the number of pairs of every group, before
+// and after the similarity rules are applied, for every comparison with
+// differences and in total, followed by the rules that explain the most
+// differences.
+func (p *Printer) PrintStats(stats []Stat, top int) {
+	w := tabwriter.NewWriter(p.Output, 0, 4, 2, ' ', 0)
+
+	fmt.Fprint(w, "\tchanged\tadded\tdeleted\tmoved\texplained\n")
+
+	row := func(name string, s Stat) {
+		fmt.Fprintf(w, "%s\t", name)
+
+		for _, group := range StatGroups {
+			fmt.Fprintf(w, "%d -> %d\t", s.Before[group], s.After[group])
+		}
+
+		fmt.Fprintf(w, "%.1f%%\n", s.Explained())
+	}
+
+	for _, s := range stats {
+		/* like git diff --stat, identical files are not listed */
+		if len(s.Before) > 0 {
+			row(s.FileA, s)
+		}
+	}
+
+	sum := SumStats(stats)
+
+	if len(stats) > 1 {
+		row("total", sum)
+	}
+
+	w.Flush()
+
+	if len(sum.Rules) == 0 {
+		return
+	}
+
+	p.PrintCyan("top rules:")
+
+	for i, count := range sum.Rules {
+		if i == top {
+			break
+		}
+
+		fmt.Fprintf(p.Output, "%6d  %s\n", count.Pairs, count.Change)
+	}
+}
+
+var threeWayClass = map[rune]string{
+	Ours:     "ours",
+	Theirs:   "theirs",
+	Both:     "both",
+	Conflict: "conflict",
+}
+
+func (p *Printer) PrintRed(format string, text ...interface{}) {
+	p.printColor("\033[0;31m", format, text...)
+}
+
+func (p *Printer) PrintGreen(format string, text ...interface{}) {
+	p.printColor("\033[0;32m", format, text...)
+}
+
+func (p *Printer) PrintCyan(format string, text ...interface{}) {
+	p.printColor("\033[0;36m", format, text...)
+}
+
+func (p *Printer) printColor(color string, format string, text ...interface{}) {
+	if p.Colorize && color != "" {
+		fmt.Fprint(p.Output, color)
+	}
+
+	fmt.Fprintf(p.Output, format, text...)
+
+	if p.Colorize && color != "" {
+		fmt.Fprint(p.Output, "\033[0m")
+	}
+
+	fmt.Fprint(p.Output, "\n")
+}
@@ -0,0 +1,136 @@
+package similardiff
+
+// Review holds the state of an interactive review of a result: the pairs
+// that are visible, the highlighted one, and the options, whose rules grow
+// as substitutions are accepted. Discarded pairs are only visible if Similar
+// is true.
+type Review struct {
+	Options Options
+	Result  Result
+	Items   []OrderedPair
+	Cursor  int
+	Similar bool
+}
+
+// NewReview starts the review of a result produced with the options.
+func NewReview(result Result, opts Options) *Review {
+	r := &Review{Options: opts, Result: result}
+
+	r.refresh()
+
+	return r
+}
+
+// Current returns the highlighted pair, if any.
+func (r *Review) Current() (OrderedPair, bool) {
+	if r.Cursor < 0 || r.Cursor >= len(r.Items) {
+		return OrderedPair{}, false
+	}
+
+	return r.Items[r.Cursor], true
+}
+
+// Move moves the cursor forward or backwards by a number of pairs.
+func (r *Review) Move(delta int) {
+	r.Cursor += delta
+
+	if r.Cursor >= len(r.Items) {
+		r.Cursor = len(r.Items) - 1
+	}
+
+	if r.Cursor < 0 {
+		r.Cursor = 0
+	}
+}
+
+// MoveHunk moves the cursor to the first pair of the next or previous hunk;
+// consecutive pairs with consecutive line numbers belong to the same hunk.
+func (r *Review) MoveHunk(delta int) {
+	if len(r.Items) == 0 {
+		return
+	}
+
+	for {
+		prev := r.Cursor
+		r.Move(delta)
+
+		if r.Cursor == prev || !adjacent(r.Items[prev].Pair, r.Items[r.Cursor].Pair) {
+			break
+		}
+	}
+
+	/* move backwards to the beginning of the hunk */
+	for r.Cursor > 0 && adjacent(r.Items[r.Cursor-1].Pair, r.Items[r.Cursor].Pair) {
+		r.Cursor--
+	}
+}
+
+// ToggleSimilar shows or hides the discarded pairs.
+func (r *Review) ToggleSimilar() {
+	r.Similar = !r.Similar
+	r.refresh()
+}
+
+// Suggestion returns the first substitution that remains in the highlighted
+// pair once the rules in use are applied to it, so it never overlaps them.
+func (r *Review) Suggestion() (SimilarDiffChange, bool) {
+	item, ok := r.Current()
+
+	if !ok || item.Discarded {
+		return SimilarDiffChange{}, false
+	}
+
+	pair := item.Pair
+	pair.Left, _ = applyChangesIn(pair.Left, r.Options.rulesFor(r.Result.FileA), LanguageOf(r.Result.FileA))
+
+	subs := Substitutions(pair)
+
+	if len(subs) == 0 {
+		return SimilarDiffChange{}, false
+	}
+
+	return subs[0], true
+}
+
+// Accept adds the rule to the options and filters the pairs again.
+func (r *Review) Accept(change SimilarDiffChange) {
+	r.Options.Changes = append(r.Options.Changes, change)
+	r.Result = r.Result.Refilter(r.Options)
+	r.refresh()
+}
+
+// refresh rebuilds the list of visible pairs, keeping the cursor close to
+// the line it was pointing at.
+func (r *Review) refresh() {
+	var line int
+
+	if item, ok := r.Current(); ok {
+		line = item.Pair.LeftLine
+	}
+
+	r.Items = r.Items[:0]
+
+	for _, item := range r.Result.Ordered() {
+		if item.Discarded && !r.Similar {
+			continue
+		}
+
+		r.Items = append(r.Items, item)
+	}
+
+	r.Cursor = 0
+
+	for i, item := range r.Items {
+		if item.Pair.LeftLine != 0 && item.Pair.LeftLine <= line {
+			r.Cursor = i
+		}
+	}
+}
+
+func adjacent(a SimilarDiffPair, b SimilarDiffPair) bool {
+	near := func(x int, y int) bool {
+		return x > 0 && y > 0 && (y-x == 1 || x-y == 1)
+	}
+
+	return near(a.LeftLine, b.LeftLine) || near(a.RightLine, b.RightLine)
+}
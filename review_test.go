@@ -0,0 +1,106 @@
+package similardiff
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReview(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "import foo\nx = 1\ny = 1\nimport bar baz\nz 1.0\n",
+		"b.txt": "include foo\nx = 2\ny = 2\ninclude bar qux\nz 1.2\n",
+	})
+
+	opts := Options{
+		Changes:    []SimilarDiffChange{{Old: "import", New: "include"}},
+		Tolerances: map[string][]SimilarDiffChange{"*": {{Old: "absolute", New: "0.5", Kind: KindTolerance}}},
+	}
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReview(result, opts)
+
+	if len(r.Items) != 3 || r.Cursor != 0 {
+		t.Fatalf("unexpected review: %#v", r)
+	}
+
+	if change, ok := r.Suggestion(); !ok || change != (SimilarDiffChange{Old: "1", New: "2"}) {
+		t.Fatalf("unexpected suggestion: %#v", change)
+	}
+
+	r.Move(2)
+
+	/* the substitution explained by the existing rule is not suggested */
+	change, ok := r.Suggestion()
+
+	if !ok || change != (SimilarDiffChange{Old: "baz", New: "qux"}) {
+		t.Fatalf("unexpected suggestion: %#v", change)
+	}
+
+	r.Accept(change)
+
+	/* the pair explained by the tolerance is still discarded */
+	if len(r.Result.Pairs) != 2 || len(r.Result.Discarded) != 3 || len(r.Items) != 2 || r.Cursor != 1 {
+		t.Fatalf("unexpected review after accepting a rule: %#v", r)
+	}
+
+	r.Move(5)
+
+	if r.Cursor != 1 {
+		t.Fatalf("unexpected cursor: %d", r.Cursor)
+	}
+
+	r.Move(-5)
+
+	if r.Cursor != 0 {
+		t.Fatalf("unexpected cursor: %d", r.Cursor)
+	}
+
+	r.ToggleSimilar()
+
+	if len(r.Items) != 5 || r.Cursor != 1 {
+		t.Fatalf("unexpected review with similarities: %#v", r)
+	}
+
+	/* discarded pairs need no rules */
+	r.Move(-1)
+
+	if _, ok := r.Suggestion(); ok || !r.Items[r.Cursor].Discarded {
+		t.Fatalf("unexpected suggestion for a discarded pair: %#v", r.Items[r.Cursor])
+	}
+}
+
+func TestReviewMoveHunk(t *testing.T) {
+	result := Result{Pairs: []SimilarDiffPair{
+		{Group: Changed, Left: "a", Right: "b", LeftLine: 1, RightLine: 1},
+		{Group: Changed, Left: "c", Right: "d", LeftLine: 2, RightLine: 2},
+		{Group: Changed, Left: "e", Right: "f", LeftLine: 10, RightLine: 10},
+	}}
+
+	r := NewReview(result, Options{})
+
+	r.MoveHunk(1)
+
+	if r.Cursor != 2 {
+		t.Fatalf("expecting the second hunk; got %d", r.Cursor)
+	}
+
+	r.MoveHunk(-1)
+
+	if r.Cursor != 0 {
+		t.Fatalf("expecting the first hunk; got %d", r.Cursor)
+	}
+
+	empty := NewReview(Result{}, Options{})
+	empty.MoveHunk(1)
+
+	if _, ok := empty.Current(); ok || empty.Cursor != 0 {
+		t.Fatalf("unexpected cursor: %d", empty.Cursor)
+	}
+}
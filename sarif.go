@@ -0,0 +1,162 @@
+package similardiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifRules describes the groups of pairs, which are the rules of SARIF.
+var sarifRules = []sarifRule{
+	{ID: "changed", ShortDescription: sarifMessage{Text: "Line differs from the other file"}},
+	{ID: "added", ShortDescription: sarifMessage{Text: "Line only exists in file B"}},
+	{ID: "deleted", ShortDescription: sarifMessage{Text: "Line only exists in file A"}},
+	{ID: "moved", ShortDescription: sarifMessage{Text: "Block of lines moved within the file"}},
+	{ID: "missing", ShortDescription: sarifMessage{Text: "File only exists in one of the directories"}},
+	{ID: "error", ShortDescription: sarifMessage{Text: "File could not be compared"}},
+}
+
+// WriteSARIF writes a SARIF 2.1.0 log with one result per surviving pair.
+// Results are located in file A, except for added lines, which are located
+// in file B; the line of the other file, if any, is a related location.
+// Every file that could not be compared is an error located in the file
+// that exists.
+func WriteSARIF(w io.Writer, results []Result, failures []Failure) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "similardiff",
+			InformationURI: "https://github.com/cixtor/similardiff",
+			Rules:          sarifRules,
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, r := range results {
+		for _, pair := range r.Pairs {
+			run.Results = append(run.Results, newSARIFResult(r, pair))
+		}
+	}
+
+	for _, f := range failures {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.kind(),
+			Level:     "error",
+			Message:   sarifMessage{Text: f.Err.Error()},
+			Locations: []sarifLocation{sarifLocationOf(f.existing(), 0)},
+		})
+	}
+
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(log)
+}
+
+func newSARIFResult(r Result, pair SimilarDiffPair) sarifResult {
+	res := sarifResult{RuleID: groupNames[pair.Group], Level: "warning"}
+
+	left := sarifLocationOf(r.FileA, pair.LeftLine)
+	right := sarifLocationOf(r.FileB, pair.RightLine)
+
+	switch pair.Group {
+	case Added:
+		res.Message.Text = fmt.Sprintf("Line only exists in %s: %s", r.FileB, pair.Right)
+		res.Locations = []sarifLocation{right}
+	case Deleted:
+		res.Message.Text = fmt.Sprintf("Line does not exist in %s: %s", r.FileB, pair.Left)
+		res.Locations = []sarifLocation{left}
+	case Moved:
+		res.Message.Text = fmt.Sprintf("%d lines moved to line %d of %s", MovedLines(pair), pair.RightLine, r.FileB)
+		res.Locations = []sarifLocation{left}
+	default:
+		res.Message.Text = fmt.Sprintf("Line differs from %s: %s", r.FileB, pair.Right)
+		res.Locations = []sarifLocation{left}
+	}
+
+	/* structured documents are identified by path */
+	if pair.Path != "" {
+		res.Message.Text = pair.Path + ": " + res.Message.Text
+	}
+
+	if pair.Group == Changed || pair.Group == Moved {
+		right.ID = 1
+		res.RelatedLocations = []sarifLocation{right}
+	}
+
+	return res
+}
+
+func sarifLocationOf(name string, line int) sarifLocation {
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(name)},
+	}}
+
+	if line > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+
+	return loc
+}
@@ -0,0 +1,85 @@
+package similardiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	results := []Result{
+		{FileA: "a.txt", FileB: "b.txt", Pairs: []SimilarDiffPair{
+			{Group: Changed, Left: "x", Right: "y", LeftLine: 3, RightLine: 4},
+			{Group: Added, Right: "new", RightLine: 9},
+		}},
+		{FileA: "a.json", FileB: "b.json", Pairs: []SimilarDiffPair{
+			{Group: Deleted, Left: "1", Path: "a.b"},
+		}},
+	}
+
+	var buf bytes.Buffer
+
+	if err := WriteSARIF(&buf, results, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	out := log.Runs[0].Results
+
+	if log.Version != "2.1.0" || len(out) != 3 {
+		t.Fatalf("unexpected log: %s", buf.String())
+	}
+
+	changed := out[0].Locations[0].PhysicalLocation
+
+	if out[0].RuleID != "changed" || changed.ArtifactLocation.URI != "a.txt" || changed.Region.StartLine != 3 {
+		t.Fatalf("unexpected result: %#v", out[0])
+	}
+
+	if related := out[0].RelatedLocations[0].PhysicalLocation; related.ArtifactLocation.URI != "b.txt" || related.Region.StartLine != 4 {
+		t.Fatalf("unexpected related location: %#v", related)
+	}
+
+	if added := out[1].Locations[0].PhysicalLocation; added.ArtifactLocation.URI != "b.txt" || added.Region.StartLine != 9 {
+		t.Fatalf("unexpected location of the added line: %#v", added)
+	}
+
+	if out[2].Locations[0].PhysicalLocation.Region != nil || out[2].Message.Text != "a.b: Line does not exist in b.json: 1" {
+		t.Fatalf("unexpected result without lines: %#v", out[2])
+	}
+}
+
+func TestWriteSARIFFailures(t *testing.T) {
+	failures := []Failure{
+		{FileA: "a/one.txt", FileB: "b/one.txt", Err: &DiffError{Tool: "diff", Code: 2, Stderr: "boom"}},
+		{FileA: "a/two.txt", FileB: "b/two.txt", Err: &MissingFileError{Name: "a/two.txt"}},
+	}
+
+	var buf bytes.Buffer
+
+	if err := WriteSARIF(&buf, nil, failures); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	out := log.Runs[0].Results
+
+	if len(out) != 2 || out[0].RuleID != "error" || out[0].Level != "error" || out[1].RuleID != "missing" {
+		t.Fatalf("unexpected log: %s", buf.String())
+	}
+
+	/* the missing file is in directory A */
+	if uri := out[1].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "b/two.txt" {
+		t.Fatalf("unexpected location of the missing file: %s", uri)
+	}
+}
@@ -0,0 +1,214 @@
+package similardiff
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Server exposes the comparison engine over HTTP. MaxBytes limits the size
+// of a request body and Timeout the time spent on a comparison.
+type Server struct {
+	MaxBytes int64
+	Timeout  time.Duration
+	Options  Options
+}
+
+// CompareRequest is the body accepted by the comparison endpoint: the two
+// documents and the similarity rules used to compare them.
+type CompareRequest struct {
+	A     string        `json:"a"`
+	B     string        `json:"b"`
+	Rules []RequestRule `json:"rules"`
+	Moves bool          `json:"moves"`
+}
+
+// RequestRule is a similarity rule in JSON form, see SimilarDiffChange.
+type RequestRule struct {
+	Old  string `json:"old"`
+	New  string `json:"new"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// CompareResponse is the body returned by the comparison endpoint.
+type CompareResponse struct {
+	Pairs     []ResponsePair    `json:"pairs"`
+	Discarded []ResponseDiscard `json:"discarded"`
+	Notes     []string          `json:"notes,omitempty"`
+	Stats     ResponseStats     `json:"stats"`
+}
+
+// ResponsePair is a difference in JSON form, see SimilarDiffPair.
+type ResponsePair struct {
+	Group     string `json:"group"`
+	Left      string `json:"left,omitempty"`
+	Right     string `json:"right,omitempty"`
+	LeftLine  int    `json:"left_line,omitempty"`
+	RightLine int    `json:"right_line,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+// ResponseDiscard is a discarded difference along with the rules that
+// explain it.
+type ResponseDiscard struct {
+	Pair  ResponsePair  `json:"pair"`
+	Rules []RequestRule `json:"rules"`
+}
+
+// ResponseStats holds the number of pairs of every group, before and after
+// the rules are applied, see Stat.
+type ResponseStats struct {
+	Before    map[string]int `json:"before"`
+	After     map[string]int `json:"after"`
+	Explained float64        `json:"explained"`
+}
+
+// NewServer creates a server that accepts requests of up to 10 MiB and
+// spends up to 30 seconds on every comparison.
+func NewServer() *Server {
+	return &Server{
+		MaxBytes: 10 << 20,
+		Timeout:  30 * time.Second,
+	}
+}
+
+// Handler returns the HTTP handler of the server. The comparison endpoint is
+// "POST /compare"; errors are returned as {"error": "message"}.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	timeout := `{"error":"comparison timed out"}`
+
+	mux.Handle("/compare", http.TimeoutHandler(http.HandlerFunc(s.compare), s.Timeout, timeout))
+
+	return mux
+}
+
+func (s *Server) compare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	var req CompareRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.MaxBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	opts := s.Options
+	opts.Moves = opts.Moves || req.Moves
+	opts.Changes = append([]SimilarDiffChange{}, opts.Changes...)
+
+	for _, rule := range req.Rules {
+		if rule.Old == "" || (rule.Kind != "" && rule.Kind != KindTolerance && !isRuleKind(rule.Kind)) {
+			writeError(w, http.StatusBadRequest, ErrMalformedRule)
+			return
+		}
+
+		if rule.Kind == KindPattern {
+			if _, err := CompilePattern(rule.Old); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		if rule.Kind == KindTolerance {
+			if _, err := parseTolerance(SimilarDiffChange{Old: rule.Old, New: rule.New}); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		opts.Changes = append(opts.Changes, SimilarDiffChange{Old: rule.Old, New: rule.New, Kind: rule.Kind})
+	}
+
+	/* the context is done when the comparison times out */
+	result, err := CompareContext(r.Context(), strings.NewReader(req.A), strings.NewReader(req.B), opts)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCompareResponse(result))
+}
+
+func newCompareResponse(r Result) CompareResponse {
+	res := CompareResponse{
+		Pairs:     []ResponsePair{},
+		Discarded: []ResponseDiscard{},
+		Notes:     r.Notes,
+	}
+
+	for _, pair := range r.Pairs {
+		res.Pairs = append(res.Pairs, newResponsePair(pair))
+	}
+
+	for _, item := range r.Discarded {
+		discard := ResponseDiscard{Pair: newResponsePair(item.Pair), Rules: []RequestRule{}}
+
+		for _, rule := range item.Rules {
+			discard.Rules = append(discard.Rules, RequestRule{Old: rule.Old, New: rule.New, Kind: rule.Kind})
+		}
+
+		res.Discarded = append(res.Discarded, discard)
+	}
+
+	stat := NewStat(r)
+
+	res.Stats = ResponseStats{
+		Before:    map[string]int{},
+		After:     map[string]int{},
+		Explained: stat.Explained(),
+	}
+
+	for _, group := range StatGroups {
+		res.Stats.Before[groupNames[group]] = stat.Before[group]
+		res.Stats.After[groupNames[group]] = stat.After[group]
+	}
+
+	return res
+}
+
+func newResponsePair(pair SimilarDiffPair) ResponsePair {
+	return ResponsePair{
+		Group:     groupNames[pair.Group],
+		Left:      pair.Left,
+		Right:     pair.Right,
+		LeftLine:  pair.LeftLine,
+		RightLine: pair.RightLine,
+		Path:      pair.Path,
+	}
+}
+
+var groupNames = map[rune]string{
+	Changed: "changed",
+	Added:   "added",
+	Deleted: "deleted",
+	Moved:   "moved",
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v) /* the client is gone */
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
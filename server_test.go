@@ -0,0 +1,122 @@
+package similardiff
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerCompare(t *testing.T) {
+	ts := httptest.NewServer(NewServer().Handler())
+	defer ts.Close()
+
+	body := `{"a": "foo 1\nsame\nx\n", "b": "bar 1\nsame\nz\n", "rules": [{"old": "foo", "new": "bar"}]}`
+
+	res, err := http.Post(ts.URL+"/compare", "application/json", strings.NewReader(body))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %s", res.Status)
+	}
+
+	var out CompareResponse
+
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ResponsePair{Group: "changed", Left: "x", Right: "z", LeftLine: 3, RightLine: 3}
+
+	if len(out.Pairs) != 1 || out.Pairs[0] != expected {
+		t.Fatalf("unexpected pairs: %#v", out.Pairs)
+	}
+
+	if len(out.Discarded) != 1 || out.Discarded[0].Rules[0] != (RequestRule{Old: "foo", New: "bar"}) {
+		t.Fatalf("unexpected discarded pairs: %#v", out.Discarded)
+	}
+
+	if out.Stats.Before["changed"] != 2 || out.Stats.After["changed"] != 1 || out.Stats.Explained != 50 {
+		t.Fatalf("unexpected stats: %#v", out.Stats)
+	}
+}
+
+func TestServerCompareNoTrailingNewline(t *testing.T) {
+	ts := httptest.NewServer(NewServer().Handler())
+	defer ts.Close()
+
+	body := `{"a": "same\nfoo 1", "b": "same\nbar 1", "rules": [{"old": "foo", "new": "bar"}]}`
+
+	res, err := http.Post(ts.URL+"/compare", "application/json", strings.NewReader(body))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	var out CompareResponse
+
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Pairs) != 0 || len(out.Discarded) != 1 {
+		t.Fatalf("unexpected pairs: %#v, discarded: %#v", out.Pairs, out.Discarded)
+	}
+
+	expected := ResponsePair{Group: "changed", Left: "foo 1", Right: "bar 1", LeftLine: 2, RightLine: 2}
+
+	if out.Discarded[0].Pair != expected {
+		t.Fatalf("unexpected discarded pair: %#v", out.Discarded[0].Pair)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	s := NewServer()
+	s.MaxBytes = 64
+
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		method string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, `{"a": `, http.StatusBadRequest},
+		{http.MethodPost, `{"c": "unknown"}`, http.StatusBadRequest},
+		{http.MethodPost, `{"a": "", "b": "", "rules": [{"old": "", "new": "x"}]}`, http.StatusBadRequest},
+		{http.MethodPost, `{"a": "` + strings.Repeat("a", 64) + `", "b": ""}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, ts.URL+"/compare", strings.NewReader(test.body))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out map[string]string
+
+		err = json.NewDecoder(res.Body).Decode(&out)
+		res.Body.Close()
+
+		if res.StatusCode != test.status || err != nil || out["error"] == "" {
+			t.Fatalf("%s %q: unexpected response %s, %v, %v", test.method, test.body, res.Status, out, err)
+		}
+	}
+}
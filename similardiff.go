@@ -1,9 +1,10 @@
-package main
+// Package similardiff detects differences between two files and disregards
+// the ones that are explained by an optional list of similarities.
+package similardiff
 
 import (
-	"bufio"
-	"flag"
-	"fmt"
+	"bytes"
+	"context"
 	"os"
 	"os/exec"
 	"regexp"
@@ -11,32 +12,79 @@ import (
 	"strings"
 )
 
-const changed rune = 'c'
-const added rune = 'a'
-const deleted rune = 'd'
+// Changed marks a pair of lines that exist in both files with differences.
+const Changed rune = 'c'
+
+// Added marks a line that only exists in file B.
+const Added rune = 'a'
+
+// Deleted marks a line that only exists in file A.
+const Deleted rune = 'd'
+
+const diffTool = "/usr/bin/diff"
+
+// Hunk headers are compiled once and shared by every comparison.
+var (
+	headerChangedLinesOne           = regexp.MustCompile(`^([0-9]+)c([0-9]+)$`)
+	headerChangedLinesManyBothSides = regexp.MustCompile(`^([0-9]+),([0-9]+)c([0-9]+),([0-9]+)$`)
+	headerChangedLinesManyRightSide = regexp.MustCompile(`^([0-9]+)c([0-9]+),([0-9]+)$`)
+	headerChangedLinesManyLeftSide  = regexp.MustCompile(`^([0-9]+),([0-9]+)c([0-9]+)$`)
+	headerDeletedLinesOne           = regexp.MustCompile(`^([0-9]+)d([0-9]+)$`)
+	headerDeletedLinesMany          = regexp.MustCompile(`^([0-9]+),([0-9]+)d([0-9]+)$`)
+	headerAddedLinesOne             = regexp.MustCompile(`^([0-9]+)a([0-9]+)$`)
+	headerAddedLinesMany            = regexp.MustCompile(`^([0-9]+)a([0-9]+),([0-9]+)$`)
+)
 
 type SimilarDiff struct {
-	Cursor   int
-	FileA    string
-	FileB    string
-	Lines    []string
-	Pairs    []SimilarDiffPair
-	Changes  []SimilarDiffChange
-	Colorize bool
-	Total    int
+	Cursor    int
+	FileA     string
+	FileB     string
+	Lines     []string
+	Pairs     []SimilarDiffPair
+	Changes   []SimilarDiffChange
+	Discarded []SimilarDiffDiscard
+	Total     int
+	Language  string
 }
 
+// SimilarDiffPair is a difference between both files. Path identifies the
+// difference in structured documents, where line numbers are not available.
+// AfterLine is the line of file A after which added lines were inserted, as
+// in the "5a7" header of the diff tool; zero means before the first line.
 type SimilarDiffPair struct {
 	Group     rune
 	Left      string
 	Right     string
 	LeftLine  int
 	RightLine int
+	Path      string
+	AfterLine int
 }
 
+// SimilarDiffChange is a similarity rule that replaces Old with New. Kind
+// restricts where the rule applies, see KindWord and the other kinds.
 type SimilarDiffChange struct {
-	Old string
-	New string
+	Old  string
+	New  string
+	Kind string
+}
+
+// String returns the rule as written in the configuration file.
+func (c SimilarDiffChange) String() string {
+	if c.Kind == KindPattern {
+		return c.Old
+	}
+
+	return c.Old + "=" + c.New
+}
+
+// SimilarDiffDiscard is a pair of lines that was discarded because the
+// similarity rules explain the difference. Position is the index in Pairs
+// where the discarded pair would have been.
+type SimilarDiffDiscard struct {
+	Pair     SimilarDiffPair
+	Rules    []SimilarDiffChange
+	Position int
 }
 
 func NewSimilarDiff() *SimilarDiff {
@@ -51,73 +99,113 @@ func (s *SimilarDiff) SetFileB(name string) {
 	s.FileB = name
 }
 
-func (s *SimilarDiff) SetColorize(value string) {
-	s.Colorize = (value == "true")
-}
-
-func (s *SimilarDiff) SetChanges(name string) {
+// SetChanges loads the similarities from the configuration file located in
+// the current working directory. A missing configuration file is not an error.
+func (s *SimilarDiff) SetChanges(name string) error {
 	folder, err := os.Getwd()
 
 	if err != nil {
-		fmt.Println(err)
-		flag.Usage()
-		os.Exit(1)
+		return &ConfigError{Name: name, Err: err}
 	}
 
-	/* configuration file does not exists; skip changes */
-	if _, err := os.Stat(folder + "/" + name); os.IsNotExist(err) {
-		return
+	changes, err := LoadChanges(folder + "/" + name)
+
+	if err != nil {
+		return err
 	}
 
-	file, err := os.Open(folder + "/" + name)
+	s.Changes = changes
 
-	if err != nil {
-		fmt.Println(err)
-		flag.Usage()
-		os.Exit(1)
+	return nil
+}
+
+// FindChanges runs the diff tool against both files and stores its output.
+func (s *SimilarDiff) FindChanges() error {
+	return s.FindChangesContext(context.Background())
+}
+
+// FindChangesContext is like FindChanges but kills the diff tool, and returns
+// the error of the context, if the context is done before the tool ends.
+func (s *SimilarDiff) FindChangesContext(ctx context.Context) error {
+	if err := checkFiles(s.FileA, s.FileB); err != nil {
+		return err
 	}
 
-	defer file.Close()
+	var stderr bytes.Buffer
 
-	var line string
-	var parts []string
+	cmd := exec.CommandContext(ctx, diffTool, s.FileA, s.FileB)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
 
-	scanner := bufio.NewScanner(file)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-	for scanner.Scan() {
-		line = scanner.Text()
-		line = strings.TrimSpace(line)
+	if err := diffFailure(err, &stderr); err != nil {
+		return err
+	}
 
-		/* expect x=y */
-		if len(line) < 3 {
-			continue
+	s.Lines = nil
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if !noNewlineMarker(line) {
+			s.Lines = append(s.Lines, line)
 		}
+	}
 
-		/* skip comments */
-		if line[0] == '#' {
-			continue
+	s.Total = len(s.Lines)
+
+	return nil
+}
+
+// noNewlineMarker reports whether the line of the diff output is the remark
+// "\\ No newline at end of file", which follows the last line of a file
+// without a trailing newline and is not part of the content.
+func noNewlineMarker(line string) bool {
+	return strings.HasPrefix(line, "\\ ")
+}
+
+// checkFiles returns an error if any of the files does not exist.
+func checkFiles(names ...string) error {
+	for _, name := range names {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return &MissingFileError{Name: name}
 		}
+	}
+
+	return nil
+}
 
-		parts = strings.Split(scanner.Text(), "=")
+// diffFailure converts the error returned by the diff tool into a DiffError,
+// or returns nil if the tool finished successfully.
+func diffFailure(err error, stderr *bytes.Buffer) error {
+	if err == nil {
+		return nil
+	}
 
-		s.Changes = append(s.Changes, SimilarDiffChange{
-			Old: parts[0],
-			New: parts[1],
-		})
+	exitErr, ok := err.(*exec.ExitError)
+
+	/* exit(1) means there are differences */
+	if ok && exitErr.ExitCode() == 1 {
+		return nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println(err)
-		flag.Usage()
-		os.Exit(1)
+	return &DiffError{
+		Tool:   diffTool,
+		Code:   exitCode(err),
+		Stderr: strings.TrimSpace(stderr.String()),
+		Err:    err,
 	}
 }
 
-func (s *SimilarDiff) FindChanges() {
-	/* discard errors; exit(1) means there are differences */
-	out, _ := exec.Command("/usr/bin/diff", s.FileA, s.FileB).CombinedOutput()
-	s.Lines = strings.Split(string(out), "\n")
-	s.Total = len(s.Lines)
+// exitCode returns the exit status of a failed command, or -1 if the command
+// did not even start.
+func exitCode(err error) int {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+
+	return -1
 }
 
 func (s *SimilarDiff) CaptureChanges() {
@@ -172,7 +260,7 @@ func (s *SimilarDiff) CaptureChanges() {
 // --- | change separator
 // > B | content in file B
 func (s *SimilarDiff) CaptureChangedLinesOne() bool {
-	header := regexp.MustCompile(`^([0-9]+)c([0-9]+)$`)
+	header := headerChangedLinesOne
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
@@ -181,7 +269,7 @@ func (s *SimilarDiff) CaptureChangedLinesOne() bool {
 	m := header.FindStringSubmatch(s.Lines[s.Cursor])
 
 	s.Pairs = append(s.Pairs, SimilarDiffPair{
-		Group:     changed,
+		Group:     Changed,
 		Left:      s.Lines[s.Cursor+1][2:],
 		Right:     s.Lines[s.Cursor+3][2:],
 		LeftLine:  s.ConvertAtoi(m[1]),
@@ -232,7 +320,7 @@ func (s *SimilarDiff) CaptureChangedLinesOne() bool {
 // > Y       | content in file B, line 11
 // > Z       | content in file B, line 12
 func (s *SimilarDiff) CaptureChangedLinesManyBothSides() bool {
-	header := regexp.MustCompile(`^([0-9]+),([0-9]+)c([0-9]+),([0-9]+)$`)
+	header := headerChangedLinesManyBothSides
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
@@ -272,7 +360,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyBothSides() bool {
 	for i := 0; i < howmany; i++ {
 		s.Cursor++ /* move cursor ahead */
 		s.Pairs = append(s.Pairs, SimilarDiffPair{
-			Group:     changed,
+			Group:     Changed,
 			Left:      s.Lines[s.Cursor][2:],
 			Right:     s.Lines[s.Cursor+padding+1][2:],
 			LeftLine:  numLeftA + i,
@@ -288,9 +376,10 @@ func (s *SimilarDiff) CaptureChangedLinesManyBothSides() bool {
 		for i := 0; i < remaining; i++ {
 			s.Cursor++ /* move cursor ahead */
 			s.Pairs = append(s.Pairs, SimilarDiffPair{
-				Group:     added,
+				Group:     Added,
 				Right:     s.Lines[s.Cursor][2:],
 				RightLine: numRightA + howmany + i,
+				AfterLine: numLeftB,
 			})
 		}
 	}
@@ -302,7 +391,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyBothSides() bool {
 		for i := 0; i < remaining; i++ {
 			s.Cursor++ /* move cursor ahead */
 			s.Pairs = append(s.Pairs, SimilarDiffPair{
-				Group:    deleted,
+				Group:    Deleted,
 				Left:     s.Lines[s.Cursor][2:],
 				LeftLine: numLeftA + howmany + i,
 			})
@@ -321,7 +410,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyBothSides() bool {
 // > Y         | content in file B, line 302
 // > Z         | content in file B, line 303
 func (s *SimilarDiff) CaptureChangedLinesManyRightSide() bool {
-	header := regexp.MustCompile(`^([0-9]+)c([0-9]+),([0-9]+)$`)
+	header := headerChangedLinesManyRightSide
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
@@ -334,7 +423,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyRightSide() bool {
 
 	/* capture pairing differences */
 	s.Pairs = append(s.Pairs, SimilarDiffPair{
-		Group:     changed,
+		Group:     Changed,
 		Left:      s.Lines[s.Cursor+1][2:],
 		Right:     s.Lines[s.Cursor+3][2:],
 		LeftLine:  numLeftA,
@@ -347,9 +436,10 @@ func (s *SimilarDiff) CaptureChangedLinesManyRightSide() bool {
 	for i := 0; i < howmany; i++ {
 		s.Cursor++ /* move cursor ahead */
 		s.Pairs = append(s.Pairs, SimilarDiffPair{
-			Group:     added,
+			Group:     Added,
 			Right:     s.Lines[s.Cursor][2:],
 			RightLine: numRightA + i + 1,
+			AfterLine: numLeftA,
 		})
 	}
 
@@ -364,7 +454,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyRightSide() bool {
 // ---         | change separator
 // > X         | content in file B, line 130
 func (s *SimilarDiff) CaptureChangedLinesManyLeftSide() bool {
-	header := regexp.MustCompile(`^([0-9]+),([0-9]+)c([0-9]+)$`)
+	header := headerChangedLinesManyLeftSide
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
@@ -379,7 +469,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyLeftSide() bool {
 	s.Cursor++ /* move cursor ahead */
 	padding := (numLeftB - numLeftA) + 2
 	s.Pairs = append(s.Pairs, SimilarDiffPair{
-		Group:     changed,
+		Group:     Changed,
 		Left:      s.Lines[s.Cursor][2:],
 		Right:     s.Lines[s.Cursor+padding][2:],
 		LeftLine:  numLeftA,
@@ -391,7 +481,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyLeftSide() bool {
 	for i := 0; i < remaining; i++ {
 		s.Cursor++ /* move cursor ahead */
 		s.Pairs = append(s.Pairs, SimilarDiffPair{
-			Group:    deleted,
+			Group:    Deleted,
 			Left:     s.Lines[s.Cursor][2:],
 			LeftLine: numLeftA + i + 1,
 		})
@@ -406,7 +496,7 @@ func (s *SimilarDiff) CaptureChangedLinesManyLeftSide() bool {
 // 43d22 | changed lines (file A, file B)
 // < B   | content in file A, line 43
 func (s *SimilarDiff) CaptureDeletedLinesOne() bool {
-	header := regexp.MustCompile(`^([0-9]+)d([0-9]+)$`)
+	header := headerDeletedLinesOne
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
@@ -417,7 +507,7 @@ func (s *SimilarDiff) CaptureDeletedLinesOne() bool {
 	s.Cursor++ /* move cursor ahead */
 
 	s.Pairs = append(s.Pairs, SimilarDiffPair{
-		Group:    deleted,
+		Group:    Deleted,
 		Left:     s.Lines[s.Cursor][2:],
 		LeftLine: s.ConvertAtoi(m[1]),
 	})
@@ -432,7 +522,7 @@ func (s *SimilarDiff) CaptureDeletedLinesOne() bool {
 // < Y     | content in file A, line 12
 // < Z     | content in file A, line 13
 func (s *SimilarDiff) CaptureDeletedLinesMany() bool {
-	header := regexp.MustCompile(`^([0-9]+),([0-9]+)d([0-9]+)$`)
+	header := headerDeletedLinesMany
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
@@ -445,7 +535,7 @@ func (s *SimilarDiff) CaptureDeletedLinesMany() bool {
 	for i := numLeftA; i <= numLeftB; i++ {
 		s.Cursor++ /* move cursor ahead */
 		s.Pairs = append(s.Pairs, SimilarDiffPair{
-			Group:    deleted,
+			Group:    Deleted,
 			Left:     s.Lines[s.Cursor][2:],
 			LeftLine: i, /* real line number */
 		})
@@ -460,7 +550,7 @@ func (s *SimilarDiff) CaptureDeletedLinesMany() bool {
 // 5a20 | changed lines (file A, file B)
 // > B  | content in file B, line 20
 func (s *SimilarDiff) CaptureAddedLinesOne() bool {
-	header := regexp.MustCompile(`^([0-9]+)a([0-9]+)$`)
+	header := headerAddedLinesOne
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
@@ -471,9 +561,10 @@ func (s *SimilarDiff) CaptureAddedLinesOne() bool {
 	s.Cursor++ /* move cursor ahead */
 
 	s.Pairs = append(s.Pairs, SimilarDiffPair{
-		Group:     added,
+		Group:     Added,
 		Right:     s.Lines[s.Cursor][2:],
 		RightLine: s.ConvertAtoi(m[2]),
+		AfterLine: s.ConvertAtoi(m[1]),
 	})
 
 	return true
@@ -486,30 +577,36 @@ func (s *SimilarDiff) CaptureAddedLinesOne() bool {
 // > Y     | content in file B, line 12
 // > Z     | content in file B, line 13
 func (s *SimilarDiff) CaptureAddedLinesMany() bool {
-	header := regexp.MustCompile(`^([0-9]+)a([0-9]+),([0-9]+)$`)
+	header := headerAddedLinesMany
 
 	if header.FindString(s.Lines[s.Cursor]) == "" {
 		return false
 	}
 
 	m := header.FindStringSubmatch(s.Lines[s.Cursor])
+	numLeftA := s.ConvertAtoi(m[1])  /* 5a10,13 -> 5 */
 	numRightA := s.ConvertAtoi(m[2]) /* 5a10,13 -> 10 */
 	numRightB := s.ConvertAtoi(m[3]) /* 5a10,13 -> 13 */
 
 	for i := numRightA; i <= numRightB; i++ {
 		s.Cursor++ /* move cursor ahead */
 		s.Pairs = append(s.Pairs, SimilarDiffPair{
-			Group:     added,
+			Group:     Added,
 			Right:     s.Lines[s.Cursor][2:],
 			RightLine: i, /* real line number */
+			AfterLine: numLeftA,
 		})
 	}
 
 	return true
 }
 
+// DiscardSimilarities removes the changed pairs that become identical once
+// the similarity rules are applied to the left side. The removed pairs are
+// kept in Discarded along with the rules that explain them.
 func (s *SimilarDiff) DiscardSimilarities() {
-	var temp string
+	var similar bool
+	var rules []SimilarDiffChange
 	var group SimilarDiffPair
 
 	totalPairs := len(s.Pairs)
@@ -518,20 +615,21 @@ func (s *SimilarDiff) DiscardSimilarities() {
 	for i := 0; i < totalPairs; i++ {
 		group = s.Pairs[i]
 
-		/* cannot compare lines that were added or deleted */
-		if group.Group == added || group.Group == deleted {
+		/* cannot compare lines that were added, deleted or moved */
+		if group.Group == Added || group.Group == Deleted || group.Group == Moved {
 			notDiscarded = append(notDiscarded, group)
 			continue
 		}
 
-		temp = group.Left
-
-		for _, change := range s.Changes {
-			temp = strings.Replace(temp, change.Old, change.New, -1)
-		}
+		similar, rules = similarLines(group.Left, group.Right, s.Changes, s.Language)
 
 		/* lines are similar */
-		if temp == group.Right {
+		if similar {
+			s.Discarded = append(s.Discarded, SimilarDiffDiscard{
+				Pair:     group,
+				Rules:    rules,
+				Position: len(notDiscarded),
+			})
 			continue
 		}
 
@@ -541,30 +639,25 @@ func (s *SimilarDiff) DiscardSimilarities() {
 	s.Pairs = notDiscarded
 }
 
-func (s *SimilarDiff) PrettyPrint() {
-	s.FindChanges() /* read and run diff */
-
-	s.CaptureChanges() /* find and process */
-
-	s.DiscardSimilarities()
-
-	/* there are no changes */
-	if len(s.Pairs) <= 0 {
-		return
-	}
-
-	s.PrintRed("--- %s", s.FileA)
-	s.PrintGreen("+++ %s", s.FileB)
+// applyChanges replaces every similarity in the line, in order, and returns
+// the resulting line along with the rules that modified it.
+func applyChanges(line string, changes []SimilarDiffChange) (string, []SimilarDiffChange) {
+	return applyChangesIn(line, changes, "")
+}
 
-	for _, group := range s.Pairs {
-		if group.LeftLine > 0 {
-			s.PrintRed("%d\t-%s", group.LeftLine, group.Left)
-		}
+// applyChangesIn is like applyChanges for a line written in the language
+// returned by LanguageOf.
+func applyChangesIn(line string, changes []SimilarDiffChange, lang string) (string, []SimilarDiffChange) {
+	var rules []SimilarDiffChange
+	var modified bool
 
-		if group.RightLine > 0 {
-			s.PrintGreen("%d\t+%s", group.RightLine, group.Right)
+	for _, change := range changes {
+		if line, modified = applyRule(line, change, lang); modified {
+			rules = append(rules, change)
 		}
 	}
+
+	return line, rules
 }
 
 func (s *SimilarDiff) ConvertAtoi(number string) int {
@@ -576,68 +669,3 @@ func (s *SimilarDiff) ConvertAtoi(number string) int {
 
 	return num
 }
-
-func (s *SimilarDiff) PrintRed(format string, text ...interface{}) {
-	if s.Colorize {
-		fmt.Print("\033[0;31m")
-	}
-
-	fmt.Printf(format, text...)
-
-	if s.Colorize {
-		fmt.Print("\033[0m")
-	}
-
-	fmt.Print("\n")
-}
-
-func (s *SimilarDiff) PrintGreen(format string, text ...interface{}) {
-	if s.Colorize {
-		fmt.Print("\033[0;32m")
-	}
-
-	fmt.Printf(format, text...)
-
-	if s.Colorize {
-		fmt.Print("\033[0m")
-	}
-
-	fmt.Print("\n")
-}
-
-func main() {
-	flag.Usage = func() {
-		fmt.Println("Similar Diff")
-		fmt.Println("https://cixtor.com/")
-		fmt.Println("https://github.com/cixtor/similardiff")
-		fmt.Println("https://en.wikipedia.org/wiki/Edit_distance")
-		fmt.Println("https://en.wikipedia.org/wiki/File_comparison")
-		fmt.Println("https://en.wikipedia.org/wiki/Levenshtein_distance")
-		fmt.Println()
-		fmt.Println("Usage:")
-		fmt.Println("  similardiff [FILE_A] [FILE_B]")
-		fmt.Println()
-		fmt.Println("Settings:")
-		fmt.Println("  export SIMILARDIFF_COLOR=true")
-		fmt.Println("  echo \"#file_a:file_b\" 1>> similardiff.ini")
-		fmt.Println("  echo \"import:include\" 1>> similardiff.ini")
-		fmt.Println("  echo \"package:module\" 1>> similardiff.ini")
-		fmt.Println("  similardiff file_a.txt file_b.txt")
-	}
-
-	flag.Parse()
-
-	if flag.NArg() == 0 {
-		flag.Usage()
-		os.Exit(2)
-	}
-
-	s := NewSimilarDiff()
-
-	s.SetFileA(flag.Arg(0))
-	s.SetFileB(flag.Arg(1))
-	s.SetChanges("similardiff.ini")
-	s.SetColorize(os.Getenv("SIMILARDIFF_COLOR"))
-
-	s.PrettyPrint()
-}
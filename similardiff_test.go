@@ -1,6 +1,8 @@
-package main
+package similardiff
 
 import (
+	"errors"
+	"path/filepath"
 	"testing"
 )
 
@@ -183,6 +185,7 @@ func TestCaptureChangedLinesManyBothSidesAdded(t *testing.T) {
 		Right:     "Y | content in file B, line 13",
 		LeftLine:  0,
 		RightLine: 13,
+		AfterLine: 3,
 	}
 	expected[4] = SimilarDiffPair{
 		Group:     'a',
@@ -190,6 +193,7 @@ func TestCaptureChangedLinesManyBothSidesAdded(t *testing.T) {
 		Right:     "Z | content in file B, line 14",
 		LeftLine:  0,
 		RightLine: 14,
+		AfterLine: 3,
 	}
 
 	CheckTestData(t, s, 5, expected)
@@ -331,6 +335,7 @@ func TestCaptureChangedLinesManyRightSide(t *testing.T) {
 		Right:     "content in file B, line 301",
 		LeftLine:  0,
 		RightLine: 301,
+		AfterLine: 296,
 	}
 	expected[2] = SimilarDiffPair{
 		Group:     'a',
@@ -338,6 +343,7 @@ func TestCaptureChangedLinesManyRightSide(t *testing.T) {
 		Right:     "content in file B, line 302",
 		LeftLine:  0,
 		RightLine: 302,
+		AfterLine: 296,
 	}
 	expected[3] = SimilarDiffPair{
 		Group:     'a',
@@ -345,6 +351,7 @@ func TestCaptureChangedLinesManyRightSide(t *testing.T) {
 		Right:     "content in file B, line 303",
 		LeftLine:  0,
 		RightLine: 303,
+		AfterLine: 296,
 	}
 
 	CheckTestData(t, s, 4, expected)
@@ -455,6 +462,7 @@ func TestCaptureAddedLinesOne(t *testing.T) {
 		Right:     "A | content in file B, line 10",
 		LeftLine:  0,
 		RightLine: 10,
+		AfterLine: 5,
 	}
 	expected[1] = SimilarDiffPair{
 		Group:     'a',
@@ -462,6 +470,7 @@ func TestCaptureAddedLinesOne(t *testing.T) {
 		Right:     "B | content in file B, line 20",
 		LeftLine:  0,
 		RightLine: 20,
+		AfterLine: 5,
 	}
 
 	CheckTestData(t, s, 2, expected)
@@ -490,6 +499,7 @@ func TestCaptureAddedLinesMany(t *testing.T) {
 		Right:     "W     | content in file B, line 10",
 		LeftLine:  0,
 		RightLine: 10,
+		AfterLine: 5,
 	}
 	expected[1] = SimilarDiffPair{
 		Group:     'a',
@@ -497,6 +507,7 @@ func TestCaptureAddedLinesMany(t *testing.T) {
 		Right:     "X     | content in file B, line 11",
 		LeftLine:  0,
 		RightLine: 11,
+		AfterLine: 5,
 	}
 	expected[2] = SimilarDiffPair{
 		Group:     'a',
@@ -504,6 +515,7 @@ func TestCaptureAddedLinesMany(t *testing.T) {
 		Right:     "Y     | content in file B, line 12",
 		LeftLine:  0,
 		RightLine: 12,
+		AfterLine: 5,
 	}
 	expected[3] = SimilarDiffPair{
 		Group:     'a',
@@ -511,6 +523,7 @@ func TestCaptureAddedLinesMany(t *testing.T) {
 		Right:     "Z     | content in file B, line 13",
 		LeftLine:  0,
 		RightLine: 13,
+		AfterLine: 5,
 	}
 
 	CheckTestData(t, s, 4, expected)
@@ -528,9 +541,9 @@ func TestDiscardSimilarities(t *testing.T) {
 
 	s.Total = len(s.Lines)
 
-	s.Changes = append(s.Changes, SimilarDiffChange{"content", "foo"})
-	s.Changes = append(s.Changes, SimilarDiffChange{"file", "bar"})
-	s.Changes = append(s.Changes, SimilarDiffChange{"line", "lorem"})
+	s.Changes = append(s.Changes, SimilarDiffChange{Old: "content", New: "foo"})
+	s.Changes = append(s.Changes, SimilarDiffChange{Old: "file", New: "bar"})
+	s.Changes = append(s.Changes, SimilarDiffChange{Old: "line", New: "lorem"})
 
 	s.CaptureChanges()
 
@@ -584,3 +597,25 @@ func TestNoConfiguration(t *testing.T) {
 
 	CheckTestData(t, s, 1, expected)
 }
+
+func TestAppendChange(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "similardiff.ini")
+
+	if err := AppendChange(config, SimilarDiffChange{Old: "import", New: "include"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AppendChange(config, SimilarDiffChange{Old: "a=b", New: "c"}); !errors.Is(err, ErrMalformedRule) {
+		t.Fatalf("expecting ErrMalformedRule; got %#v", err)
+	}
+
+	changes, err := LoadChanges(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0] != (SimilarDiffChange{Old: "import", New: "include"}) {
+		t.Fatalf("unexpected changes: %#v", changes)
+	}
+}
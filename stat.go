@@ -0,0 +1,132 @@
+package similardiff
+
+import (
+	"sort"
+)
+
+// StatGroups are the groups counted by the summary, in display order.
+var StatGroups = []rune{Changed, Added, Deleted, Moved}
+
+// Stat summarizes the differences of one or more comparisons before and
+// after the similarity rules are applied. Discarded is the number of pairs
+// explained by the rules, and Rules lists how many of them every rule helped
+// explain, the rules that explain more pairs first.
+type Stat struct {
+	FileA     string
+	FileB     string
+	Before    map[rune]int
+	After     map[rune]int
+	Discarded int
+	Rules     []RuleCount
+}
+
+// RuleCount is the number of discarded pairs that a rule helped explain.
+type RuleCount struct {
+	Change SimilarDiffChange
+	Pairs  int
+}
+
+// NewStat counts the pairs of the result by group. The pairs discarded by
+// the rules and the ones suppressed by a baseline only count before.
+func NewStat(r Result) Stat {
+	s := Stat{
+		FileA:     r.FileA,
+		FileB:     r.FileB,
+		Before:    map[rune]int{},
+		After:     map[rune]int{},
+		Discarded: len(r.Discarded),
+	}
+
+	for _, pair := range r.Pairs {
+		s.Before[pair.Group]++
+		s.After[pair.Group]++
+	}
+
+	for _, pair := range r.Suppressed {
+		s.Before[pair.Group]++
+	}
+
+	for _, item := range r.Discarded {
+		s.Before[item.Pair.Group]++
+	}
+
+	s.Rules = countRules(r.Discarded)
+
+	return s
+}
+
+// SumStats adds up the counts of several comparisons.
+func SumStats(stats []Stat) Stat {
+	sum := Stat{Before: map[rune]int{}, After: map[rune]int{}}
+	rules := map[SimilarDiffChange]int{}
+
+	for _, s := range stats {
+		for group, n := range s.Before {
+			sum.Before[group] += n
+		}
+
+		for group, n := range s.After {
+			sum.After[group] += n
+		}
+
+		sum.Discarded += s.Discarded
+
+		for _, count := range s.Rules {
+			if _, ok := rules[count.Change]; !ok {
+				rules[count.Change] = len(sum.Rules)
+				sum.Rules = append(sum.Rules, RuleCount{Change: count.Change})
+			}
+
+			sum.Rules[rules[count.Change]].Pairs += count.Pairs
+		}
+	}
+
+	sortRules(sum.Rules)
+
+	return sum
+}
+
+// Explained returns the percentage of the differences that the rules
+// discarded.
+func (s Stat) Explained() float64 {
+	var before int
+
+	for _, n := range s.Before {
+		before += n
+	}
+
+	if before == 0 {
+		return 0
+	}
+
+	return 100 * float64(s.Discarded) / float64(before)
+}
+
+func countRules(discarded []SimilarDiffDiscard) []RuleCount {
+	var counts []RuleCount
+
+	index := map[SimilarDiffChange]int{}
+
+	for _, item := range discarded {
+		for _, rule := range item.Rules {
+			if _, ok := index[rule]; !ok {
+				index[rule] = len(counts)
+				counts = append(counts, RuleCount{Change: rule})
+			}
+
+			counts[index[rule]].Pairs++
+		}
+	}
+
+	sortRules(counts)
+
+	return counts
+}
+
+// sortRules puts the rules that explain more pairs first; rules with the
+// same count keep the order in which they were first used.
+func sortRules(counts []RuleCount) {
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Pairs > counts[j].Pairs
+	})
+}
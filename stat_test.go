@@ -0,0 +1,61 @@
+package similardiff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewStat(t *testing.T) {
+	foo := SimilarDiffChange{Old: "foo", New: "bar"}
+	x := SimilarDiffChange{Old: "x", New: "y"}
+
+	result := Result{
+		FileA: "a.txt",
+		FileB: "b.txt",
+		Pairs: []SimilarDiffPair{
+			{Group: Changed, Left: "x", Right: "z", LeftLine: 3, RightLine: 3},
+			{Group: Added, Right: "added", RightLine: 5},
+		},
+		Discarded: []SimilarDiffDiscard{
+			{Pair: SimilarDiffPair{Group: Changed, Left: "foo 1", Right: "bar 1", LeftLine: 1, RightLine: 1}, Rules: []SimilarDiffChange{foo}},
+			{Pair: SimilarDiffPair{Group: Changed, Left: "foo x", Right: "bar y", LeftLine: 2, RightLine: 2}, Rules: []SimilarDiffChange{x, foo}},
+		},
+	}
+
+	s := NewStat(result)
+
+	if s.Before[Changed] != 3 || s.After[Changed] != 1 || s.Before[Added] != 1 || s.After[Added] != 1 {
+		t.Fatalf("unexpected counts: %v -> %v", s.Before, s.After)
+	}
+
+	if s.Explained() != 50 {
+		t.Fatalf("unexpected percentage: %f", s.Explained())
+	}
+
+	expected := []RuleCount{{Change: foo, Pairs: 2}, {Change: x, Pairs: 1}}
+
+	if len(s.Rules) != 2 || s.Rules[0] != expected[0] || s.Rules[1] != expected[1] {
+		t.Fatalf("unexpected rules: %#v", s.Rules)
+	}
+
+	sum := SumStats([]Stat{s, s, NewStat(Result{FileA: "c.txt"})})
+
+	if sum.Before[Changed] != 6 || sum.Discarded != 4 || sum.Rules[0].Pairs != 4 {
+		t.Fatalf("unexpected sum: %#v", sum)
+	}
+
+	var buf bytes.Buffer
+
+	NewPrinter(&buf).PrintStats([]Stat{s, NewStat(Result{FileA: "c.txt"})}, 1)
+
+	output := buf.String()
+
+	if !strings.Contains(output, "a.txt  3 -> 1") || strings.Contains(output, "c.txt") {
+		t.Fatalf("unexpected summary:\n%s", output)
+	}
+
+	if !strings.HasSuffix(output, "top rules:\n     2  foo=bar\n") {
+		t.Fatalf("unexpected top rules:\n%s", output)
+	}
+}
@@ -0,0 +1,176 @@
+package similardiff
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// hunkHeader matches the line that opens a hunk in the normal diff format,
+// for example "1c1", "1,3c7,9", "13d12" or "5a10,13".
+var hunkHeader = regexp.MustCompile(`^[0-9]+(,[0-9]+)?[acd][0-9]+(,[0-9]+)?$`)
+
+// CompareStream detects the differences between two files like CompareFiles
+// but, instead of accumulating the entire diff in memory, it reads the output
+// of the diff tool hunk by hunk and sends every pair that survives the
+// similarity rules to emit as soon as its hunk is processed. Memory usage is
+// bounded by the size of the largest hunk.
+//
+// The remarks about the files as a whole, the same as the Notes of a Result,
+// are sent to note, if not nil, before any pair.
+//
+// The comparison stops at the first error returned by note or emit.
+func CompareStream(fileA string, fileB string, opts Options, note func(string) error, emit func(SimilarDiffPair) error) error {
+	if err := checkFiles(fileA, fileB); err != nil {
+		return err
+	}
+
+	for _, name := range []string{fileA, fileB} {
+		if binary, _ := IsBinary(name); binary {
+			return emitBinary(fileA, fileB, opts, note, emit)
+		}
+	}
+
+	nameA, nameB, notes, cleanup, err := prepareFiles(fileA, fileB)
+
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	if err := emitNotes(notes, note); err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(diffTool, nameA, nameB)
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		return &DiffError{Tool: diffTool, Code: -1, Err: err}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return &DiffError{Tool: diffTool, Code: -1, Err: err}
+	}
+
+	opts.Changes = opts.rulesFor(fileA)
+
+	if err := streamHunks(stdout, LanguageOf(fileA), opts, emit); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	return diffFailure(cmd.Wait(), &stderr)
+}
+
+// emitBinary sends the pairs of a binary comparison, which is not streamed.
+func emitBinary(fileA string, fileB string, opts Options, note func(string) error, emit func(SimilarDiffPair) error) error {
+	result, err := CompareBinary(fileA, fileB, opts)
+
+	if err != nil {
+		return err
+	}
+
+	if err := emitNotes(result.Notes, note); err != nil {
+		return err
+	}
+
+	for _, pair := range result.Pairs {
+		if err := emit(pair); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// emitNotes sends the notes to the function, if any.
+func emitNotes(notes []string, note func(string) error) error {
+	if note == nil {
+		return nil
+	}
+
+	for _, text := range notes {
+		if err := note(text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// streamHunks splits the diff output into hunks and processes them one at a
+// time through the same capture and discard stages used by CompareFiles.
+func streamHunks(r io.Reader, lang string, opts Options, emit func(SimilarDiffPair) error) error {
+	var hunk []string
+
+	reader := bufio.NewReader(r)
+
+	flush := func() error {
+		if len(hunk) == 0 {
+			return nil
+		}
+
+		s := NewSimilarDiff()
+		s.Lines = hunk
+		s.Total = len(hunk)
+		s.Changes = opts.Changes
+		s.Language = lang
+
+		s.CaptureChanges()
+
+		s.DiscardSimilarities()
+
+		hunk = hunk[:0]
+
+		for _, pair := range s.Pairs {
+			if err := emit(pair); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if line == "" && err == io.EOF {
+			break
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+
+		if noNewlineMarker(line) {
+			continue
+		}
+
+		/* a new hunk starts; process the previous one */
+		if hunkHeader.MatchString(line) {
+			if ferr := flush(); ferr != nil {
+				return ferr
+			}
+		}
+
+		hunk = append(hunk, line)
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return flush()
+}
@@ -0,0 +1,115 @@
+package similardiff
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStreamHunks(t *testing.T) {
+	output := strings.Join([]string{
+		"1c1",
+		"< import foo",
+		"---",
+		"> include foo",
+		"3,4c3,4",
+		"< package bar",
+		"< lorem",
+		"---",
+		"> module bar",
+		"> ipsum",
+		"6d5",
+		"< dolor",
+		"\\ No newline at end of file",
+		"",
+	}, "\n")
+
+	opts := Options{
+		Changes: []SimilarDiffChange{
+			{Old: "import", New: "include"},
+			{Old: "package", New: "module"},
+		},
+	}
+
+	var pairs []SimilarDiffPair
+
+	err := streamHunks(strings.NewReader(output), "", opts, func(pair SimilarDiffPair) error {
+		pairs = append(pairs, pair)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "lorem", Right: "ipsum", LeftLine: 4, RightLine: 4},
+		{Group: 'd', Left: "dolor", LeftLine: 6},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: pairs}, 2, expected)
+}
+
+func TestCompareStreamStops(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.txt")
+	fileB := filepath.Join(dir, "b.txt")
+
+	if err := os.WriteFile(fileA, []byte("A\nB\nC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fileB, []byte("X\nB\nZ\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := errors.New("stop")
+	total := 0
+
+	err := CompareStream(fileA, fileB, Options{}, nil, func(pair SimilarDiffPair) error {
+		total++
+		return stop
+	})
+
+	if err != stop {
+		t.Fatalf("expecting the error returned by emit; got %#v", err)
+	}
+
+	if total != 1 {
+		t.Fatalf("expecting one emitted pair; got %d", total)
+	}
+}
+
+func TestCompareStreamNotes(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "A\nB\n",
+		"b.txt": "A\r\nC\r\n",
+	})
+
+	var events []string
+
+	err := CompareStream(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{},
+		func(note string) error {
+			events = append(events, "# "+note)
+			return nil
+		},
+		func(pair SimilarDiffPair) error {
+			events = append(events, pair.Left+" -> "+pair.Right)
+			return nil
+		},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"# line endings differ: LF in file A, CRLF in file B", "B -> C"}
+
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected events: %q", events)
+	}
+}
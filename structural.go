@@ -0,0 +1,306 @@
+package similardiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// plainKey matches the object keys that do not need quotes in a path.
+var plainKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// IsStructured reports whether the file is a JSON or YAML document, based
+// on its extension.
+func IsStructured(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+
+	return false
+}
+
+// CompareStructured parses two JSON or YAML documents and compares them as
+// trees, so formatting and the order of the keys do not matter. Every pair
+// identifies the difference by its path, for example
+// "spec.containers[0].image", and carries no line numbers. The similarity
+// rules apply to scalar values, and to keys that exist in only one side.
+func CompareStructured(fileA string, fileB string, opts Options) (Result, error) {
+	if err := checkFiles(fileA, fileB); err != nil {
+		return Result{}, err
+	}
+
+	a, err := parseStructured(fileA)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	b, err := parseStructured(fileB)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	s := NewSimilarDiff()
+
+	s.SetFileA(fileA)
+	s.SetFileB(fileB)
+	s.Changes = opts.rulesFor(fileA)
+
+	/* streams of several documents are compared as a list of roots */
+	if len(a) > 1 || len(b) > 1 {
+		s.compareLists("", a, b)
+	} else {
+		s.compareTrees("", firstDocument(a), firstDocument(b))
+	}
+
+	s.DiscardSimilarities()
+
+	return Result{
+		FileA:     fileA,
+		FileB:     fileB,
+		Pairs:     s.Pairs,
+		Discarded: s.Discarded,
+	}, nil
+}
+
+// parseStructured decodes every document of a file: YAML documents are
+// separated by "---" and JSON values by white space. JSON is decoded with
+// exact numbers.
+func parseStructured(filename string) ([]interface{}, error) {
+	var docs []interface{}
+
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		return nil, &ParseError{Name: filename, Err: err}
+	}
+
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		for {
+			var doc interface{}
+
+			if err := decoder.Decode(&doc); err != nil {
+				return nil, &ParseError{Name: filename, Err: err}
+			}
+
+			docs = append(docs, doc)
+
+			/* a closing bracket is not a value; Decode would skip it */
+			if !decoder.More() {
+				if _, err := decoder.Token(); err != io.EOF {
+					return nil, &ParseError{Name: filename, Err: errors.New("unexpected data after the document")}
+				}
+
+				break
+			}
+		}
+
+		return docs, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var doc interface{}
+
+		err := decoder.Decode(&doc)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, &ParseError{Name: filename, Err: err}
+		}
+
+		docs = append(docs, normalizeTree(doc))
+	}
+
+	return docs, nil
+}
+
+// firstDocument returns the only document of a file, or nil if it is empty.
+func firstDocument(docs []interface{}) interface{} {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	return docs[0]
+}
+
+// normalizeTree converts the maps with non-string keys that YAML allows into
+// maps with string keys, so every document has the same shape.
+func normalizeTree(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeTree(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[fmt.Sprint(key)] = normalizeTree(value)
+		}
+		return out
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeTree(v[i])
+		}
+		return v
+	}
+
+	return node
+}
+
+// compareTrees walks both nodes and captures their differences.
+func (s *SimilarDiff) compareTrees(path string, a interface{}, b interface{}) {
+	mapA, okA := a.(map[string]interface{})
+	mapB, okB := b.(map[string]interface{})
+
+	if okA && okB {
+		s.compareObjects(path, mapA, mapB)
+		return
+	}
+
+	listA, okA := a.([]interface{})
+	listB, okB := b.([]interface{})
+
+	if okA && okB {
+		s.compareLists(path, listA, listB)
+		return
+	}
+
+	left := formatNode(a)
+	right := formatNode(b)
+
+	if left == right {
+		return
+	}
+
+	s.Pairs = append(s.Pairs, SimilarDiffPair{
+		Group: Changed,
+		Left:  left,
+		Right: right,
+		Path:  rootPath(path),
+	})
+}
+
+func (s *SimilarDiff) compareObjects(path string, a map[string]interface{}, b map[string]interface{}) {
+	matched := map[string]bool{}
+
+	for _, key := range sortedNames(a) {
+		other := key
+
+		/* keys renamed according to the similarity rules */
+		if _, ok := b[key]; !ok {
+			other, _ = applyChanges(key, s.Changes)
+		}
+
+		if _, ok := b[other]; !ok || matched[other] {
+			s.Pairs = append(s.Pairs, SimilarDiffPair{
+				Group: Deleted,
+				Left:  formatNode(a[key]),
+				Path:  joinKey(path, key),
+			})
+			continue
+		}
+
+		matched[other] = true
+
+		s.compareTrees(joinKey(path, key), a[key], b[other])
+	}
+
+	for _, key := range sortedNames(b) {
+		if matched[key] {
+			continue
+		}
+
+		s.Pairs = append(s.Pairs, SimilarDiffPair{
+			Group: Added,
+			Right: formatNode(b[key]),
+			Path:  joinKey(path, key),
+		})
+	}
+}
+
+func (s *SimilarDiff) compareLists(path string, a []interface{}, b []interface{}) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		item := fmt.Sprintf("%s[%d]", path, i)
+
+		switch {
+		case i >= len(b):
+			s.Pairs = append(s.Pairs, SimilarDiffPair{Group: Deleted, Left: formatNode(a[i]), Path: item})
+		case i >= len(a):
+			s.Pairs = append(s.Pairs, SimilarDiffPair{Group: Added, Right: formatNode(b[i]), Path: item})
+		default:
+			s.compareTrees(item, a[i], b[i])
+		}
+	}
+}
+
+// formatNode returns strings as they are, and everything else as JSON.
+func formatNode(node interface{}) string {
+	switch v := node.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return "null"
+	}
+
+	data, err := json.Marshal(node)
+
+	if err != nil {
+		return fmt.Sprint(node)
+	}
+
+	return string(data)
+}
+
+func joinKey(path string, key string) string {
+	if !plainKey.MatchString(key) {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "."
+	}
+
+	return path
+}
+
+func sortedNames(m map[string]interface{}) []string {
+	names := make([]string, 0, len(m))
+
+	for name := range m {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
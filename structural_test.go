@@ -0,0 +1,134 @@
+package similardiff
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareStructured(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.json": `{
+			"kind": "Pod",
+			"spec": {"containers": [{"image": "nginx:1.0", "name": "web"}]},
+			"import_path": "lib/foo",
+			"replicas": 1.50,
+			"removed": {"x": true}
+		}`,
+		"b.yaml": "" +
+			"spec:\n" +
+			"  containers:\n" +
+			"    - name: web\n" +
+			"      image: nginx:2.0\n" +
+			"    - name: sidecar\n" +
+			"include_path: lib/foo\n" +
+			"kind: Pod\n" +
+			"replicas: 2\n",
+	})
+
+	opts := Options{
+		Changes:    []SimilarDiffChange{{Old: "import", New: "include"}},
+		Structural: true,
+	}
+
+	result, err := CompareFiles(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.yaml"), opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'd', Left: `{"x":true}`, Path: "removed"},
+		{Group: 'c', Left: "1.50", Right: "2", Path: "replicas"},
+		{Group: 'c', Left: "nginx:1.0", Right: "nginx:2.0", Path: "spec.containers[0].image"},
+		{Group: 'a', Right: `{"name":"sidecar"}`, Path: "spec.containers[1]"},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 4, expected)
+}
+
+func TestCompareStructuredParseError(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{"a.json": "{", "b.json": "{}"})
+
+	_, err := CompareStructured(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), Options{})
+
+	var parseErr *ParseError
+
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expecting ParseError; got %#v", err)
+	}
+}
+
+func TestCompareStructuredDocuments(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.json": "{\"a\": 1}\n{\"a\": 2}\n",
+		"b.json": "{\"a\": 1}\n{\"a\": 3}\n",
+		"a.yaml": "a: 1\n---\nb: 2\n",
+		"b.yaml": "a: 1\n---\nb: 3\n---\nc: 4\n",
+	})
+
+	result, err := CompareStructured(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, []SimilarDiffPair{
+		{Group: 'c', Left: "2", Right: "3", Path: "[1].a"},
+	})
+
+	result, err = CompareStructured(filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml"), Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 2, []SimilarDiffPair{
+		{Group: 'c', Left: "2", Right: "3", Path: "[1].b"},
+		{Group: 'a', Right: `{"c":4}`, Path: "[2]"},
+	})
+}
+
+func TestCompareStructuredTrailingData(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{"a.json": "{}]", "b.json": "{}"})
+
+	_, err := CompareStructured(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), Options{})
+
+	var parseErr *ParseError
+
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expecting ParseError; got %#v", err)
+	}
+}
+
+func TestCompareFilesInvalidDocument(t *testing.T) {
+	dir := t.TempDir()
+
+	/* comments are not allowed in JSON */
+	writeFiles(t, dir, map[string]string{
+		"a.json": "// settings\n{\"name\": \"foo\"}\n",
+		"b.json": "// settings\n{\"name\": \"bar\"}\n",
+	})
+
+	result, err := CompareFiles(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), Options{Structural: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, []SimilarDiffPair{
+		{Group: 'c', Left: `{"name": "foo"}`, Right: `{"name": "bar"}`, LeftLine: 2, RightLine: 2},
+	})
+
+	if len(result.Notes) != 1 {
+		t.Fatalf("expecting a note about the parse error; got %q", result.Notes)
+	}
+}
@@ -0,0 +1,287 @@
+package similardiff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// suggestMinimum is the number of pairs that must share a substitution for
+// it to be suggested; substitutions seen once are usually coincidences.
+const suggestMinimum = 2
+
+// Suggestion is a candidate similarity rule mined from the differences.
+// Eliminates is the number of pairs that the rule discards on top of the
+// existing rules and the suggestions ranked before it, and Occurrences is
+// the number of times the substitution was observed.
+type Suggestion struct {
+	Change      SimilarDiffChange
+	Eliminates  int
+	Occurrences int
+}
+
+// SuggestChanges mines the token substitutions that repeat across the changed
+// pairs and ranks them by how many pairs each one would eliminate. Only the
+// substitutions that appear in at least two pairs are suggested. The rules
+// that are already in use are applied before the pairs are analyzed.
+func SuggestChanges(pairs []SimilarDiffPair, existing []SimilarDiffChange) []Suggestion {
+	var lefts []string
+	var rights []string
+	var found [][]SimilarDiffChange
+
+	occurrences := map[SimilarDiffChange]int{}
+
+	/* positions in lefts of the pairs where every substitution appears */
+	index := map[SimilarDiffChange][]int{}
+
+	for _, pair := range pairs {
+		if pair.Group != Changed {
+			continue
+		}
+
+		left, _ := applyChanges(pair.Left, existing)
+
+		/* already explained by the existing rules */
+		if left == pair.Right {
+			continue
+		}
+
+		var unique []SimilarDiffChange
+
+		for _, change := range substitutions(tokenize(left), tokenize(pair.Right)) {
+			occurrences[change]++
+
+			if !containsChange(unique, change) {
+				unique = append(unique, change)
+				index[change] = append(index[change], len(lefts))
+			}
+		}
+
+		lefts = append(lefts, left)
+		rights = append(rights, pair.Right)
+		found = append(found, unique)
+	}
+
+	var candidates []SimilarDiffChange
+
+	for change, list := range index {
+		if len(list) >= suggestMinimum {
+			candidates = append(candidates, change)
+		}
+	}
+
+	/* deterministic order; ties are broken by the order of the candidates */
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Old == candidates[j].Old {
+			return candidates[i].New < candidates[j].New
+		}
+
+		return candidates[i].Old < candidates[j].Old
+	})
+
+	explained := make([]bool, len(lefts))
+	eliminates := make(map[SimilarDiffChange]int, len(candidates))
+
+	/* pairs that the rule explains on top of the rules selected so far */
+	count := func(change SimilarDiffChange) int {
+		var total int
+
+		for _, p := range index[change] {
+			if explained[p] {
+				continue
+			}
+
+			if left, _ := applyChanges(lefts[p], []SimilarDiffChange{change}); left == rights[p] {
+				total++
+			}
+		}
+
+		return total
+	}
+
+	for _, change := range candidates {
+		eliminates[change] = count(change)
+	}
+
+	var suggestions []Suggestion
+
+	for len(candidates) > 0 {
+		best := 0
+
+		for i, change := range candidates {
+			top := candidates[best]
+
+			if eliminates[change] > eliminates[top] ||
+				(eliminates[change] == eliminates[top] && occurrences[change] > occurrences[top]) {
+				best = i
+			}
+		}
+
+		change := candidates[best]
+
+		suggestions = append(suggestions, Suggestion{
+			Change:      change,
+			Eliminates:  eliminates[change],
+			Occurrences: occurrences[change],
+		})
+
+		candidates = append(candidates[:best], candidates[best+1:]...)
+		delete(eliminates, change)
+
+		/* the rule only modifies the pairs where it appears, so only the
+		 * rules that appear in the same pairs must be counted again */
+		stale := map[SimilarDiffChange]bool{}
+
+		for _, p := range index[change] {
+			if explained[p] {
+				continue
+			}
+
+			lefts[p], _ = applyChanges(lefts[p], []SimilarDiffChange{change})
+			explained[p] = lefts[p] == rights[p]
+
+			for _, other := range found[p] {
+				stale[other] = true
+			}
+		}
+
+		for other := range stale {
+			if _, ok := eliminates[other]; ok {
+				eliminates[other] = count(other)
+			}
+		}
+	}
+
+	return suggestions
+}
+
+func containsChange(changes []SimilarDiffChange, change SimilarDiffChange) bool {
+	for _, c := range changes {
+		if c == change {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteSuggestions writes the suggestions in the format of the configuration
+// file, each rule is preceded by a comment with its statistics.
+func WriteSuggestions(w io.Writer, suggestions []Suggestion) error {
+	for _, s := range suggestions {
+		_, err := fmt.Fprintf(w, "# eliminates %d pairs, seen %d times\n%s=%s\n",
+			s.Eliminates, s.Occurrences, s.Change.Old, s.Change.New)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Substitutions returns the token substitutions that turn the left side of
+// a changed pair into the right side.
+func Substitutions(pair SimilarDiffPair) []SimilarDiffChange {
+	if pair.Group != Changed {
+		return nil
+	}
+
+	return substitutions(tokenize(pair.Left), tokenize(pair.Right))
+}
+
+// tokenize splits a line into words, made of letters, digits and underscores,
+// runs of white spaces, and individual symbols.
+func tokenize(line string) []string {
+	var tokens []string
+
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); {
+		j := i + 1
+
+		switch {
+		case isWord(runes[i]):
+			for j < len(runes) && isWord(runes[j]) {
+				j++
+			}
+		case unicode.IsSpace(runes[i]):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		}
+
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+
+	return tokens
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// substitutions aligns both lists of tokens using their longest common
+// subsequence, and returns the text replaced in every gap between them.
+func substitutions(a []string, b []string) []SimilarDiffChange {
+	/* lcs[i][j] is the length of the LCS of a[i:] and b[j:] */
+	lcs := make([][]int, len(a)+1)
+
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []SimilarDiffChange
+	var gapA []string
+	var gapB []string
+
+	flush := func() {
+		old := strings.TrimSpace(strings.Join(gapA, ""))
+		new := strings.TrimSpace(strings.Join(gapB, ""))
+
+		/* the configuration format cannot express these */
+		if old != "" && new != "" && !strings.Contains(old, "=") && !strings.Contains(new, "=") {
+			out = append(out, SimilarDiffChange{Old: old, New: new})
+		}
+
+		gapA, gapB = nil, nil
+	}
+
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			flush()
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			gapA = append(gapA, a[i])
+			i++
+		} else {
+			gapB = append(gapB, b[j])
+			j++
+		}
+	}
+
+	gapA = append(gapA, a[i:]...)
+	gapB = append(gapB, b[j:]...)
+
+	flush()
+
+	return out
+}
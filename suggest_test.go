@@ -0,0 +1,94 @@
+package similardiff
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := tokenize("import (foo_bar)  x1")
+	expected := []string{"import", " ", "(", "foo_bar", ")", "  ", "x1"}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("unexpected tokens: %#v", tokens)
+	}
+
+	for i := range tokens {
+		if tokens[i] != expected[i] {
+			t.Fatalf("unexpected tokens: %#v", tokens)
+		}
+	}
+}
+
+func TestSuggestChanges(t *testing.T) {
+	pairs := []SimilarDiffPair{
+		{Group: Changed, Left: "import foo", Right: "include foo"},
+		{Group: Changed, Left: "import bar", Right: "include bar"},
+		{Group: Changed, Left: "import baz", Right: "include baz"},
+		{Group: Changed, Left: "package foo", Right: "module foo"},
+		{Group: Changed, Left: "package import", Right: "module include"},
+		{Group: Changed, Left: "lorem", Right: "ipsum"},
+		{Group: Changed, Left: "a = 1 + 1", Right: "a = 2 + 2"},
+		{Group: Changed, Left: "var x", Right: "let x"},
+		{Group: Added, Right: "import"},
+	}
+
+	existing := []SimilarDiffChange{{Old: "var", New: "let"}}
+
+	/* substitutions found in a single pair are not suggested */
+
+	suggestions := SuggestChanges(pairs, existing)
+
+	expected := []Suggestion{
+		{Change: SimilarDiffChange{Old: "import", New: "include"}, Eliminates: 3, Occurrences: 4},
+		{Change: SimilarDiffChange{Old: "package", New: "module"}, Eliminates: 2, Occurrences: 2},
+	}
+
+	if len(suggestions) != len(expected) {
+		t.Fatalf("unexpected suggestions: %#v", suggestions)
+	}
+
+	for i := range suggestions {
+		if suggestions[i] != expected[i] {
+			t.Fatalf("unexpected suggestion %d: %#v", i, suggestions[i])
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if err := WriteSuggestions(&buf, suggestions[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "# eliminates 3 pairs, seen 4 times\nimport=include\n" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestSuggestChangesEliminates(t *testing.T) {
+	/* foo=bar appears in more pairs, but never explains one on its own */
+	pairs := []SimilarDiffPair{
+		{Group: Changed, Left: "foo 1", Right: "bar 2"},
+		{Group: Changed, Left: "foo 3", Right: "bar 4"},
+		{Group: Changed, Left: "foo 5", Right: "bar 6"},
+		{Group: Changed, Left: "get x", Right: "fetch x"},
+		{Group: Changed, Left: "get y", Right: "fetch y"},
+	}
+
+	suggestions := SuggestChanges(pairs, nil)
+
+	expected := []Suggestion{
+		{Change: SimilarDiffChange{Old: "get", New: "fetch"}, Eliminates: 2, Occurrences: 2},
+		{Change: SimilarDiffChange{Old: "foo", New: "bar"}, Eliminates: 0, Occurrences: 3},
+	}
+
+	if len(suggestions) != len(expected) {
+		t.Fatalf("unexpected suggestions: %#v", suggestions)
+	}
+
+	for i := range suggestions {
+		if suggestions[i] != expected[i] {
+			t.Fatalf("unexpected suggestion %d: %#v", i, suggestions[i])
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package similardiff
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// table is a parsed CSV or TSV file.
+type table struct {
+	header []string
+	index  map[string]int
+	rows   [][]string
+	lines  []int
+}
+
+// IsTabular reports whether the file is a CSV or TSV file, based on its
+// extension.
+func IsTabular(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".tsv":
+		return true
+	}
+
+	return false
+}
+
+// CompareTables compares two CSV or TSV files row by row. Rows are matched by
+// the values of the key columns, so the order of the rows does not matter;
+// the first column is the key if none is configured. Every changed cell is
+// reported as a changed pair, and rows that exist in only one file as added
+// or deleted pairs. Paths identify the rows and columns, for example
+// "row[id=42].price", and line numbers point to the records in each file.
+//
+// The global similarity rules and the rules of each column are applied to
+// the cells, and the ignored columns are not compared at all.
+func CompareTables(fileA string, fileB string, opts Options) (Result, error) {
+	if err := checkFiles(fileA, fileB); err != nil {
+		return Result{}, err
+	}
+
+	a, err := readTable(fileA)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	b, err := readTable(fileB)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	keys := opts.Keys
+
+	if len(keys) == 0 && len(a.header) > 0 {
+		keys = a.header[:1]
+	}
+
+	for _, key := range keys {
+		if _, ok := a.index[key]; !ok {
+			return Result{}, &ParseError{Name: fileA, Err: fmt.Errorf("missing key column %q", key)}
+		}
+
+		if _, ok := b.index[key]; !ok {
+			return Result{}, &ParseError{Name: fileB, Err: fmt.Errorf("missing key column %q", key)}
+		}
+	}
+
+	ignored := map[string]bool{}
+
+	for _, name := range opts.Ignore {
+		ignored[name] = true
+	}
+
+	/* columns of file A first, then the ones that only exist in file B */
+	var columns []string
+
+	for _, name := range append(append([]string{}, a.header...), b.header...) {
+		if !ignored[name] && !contains(columns, name) {
+			columns = append(columns, name)
+		}
+	}
+
+	s := NewSimilarDiff()
+
+	s.SetFileA(fileA)
+	s.SetFileB(fileB)
+	s.Changes = opts.rulesFor(fileA)
+
+	/* rows of file B indexed by key; duplicated keys are matched in order */
+	pending := map[string][]int{}
+
+	for i := range b.rows {
+		id := b.rowKey(i, keys)
+		pending[id] = append(pending[id], i)
+	}
+
+	for i := range a.rows {
+		id := a.rowKey(i, keys)
+		path := rowPath(a, i, keys)
+
+		if len(pending[id]) == 0 {
+			s.Pairs = append(s.Pairs, SimilarDiffPair{
+				Group:    Deleted,
+				Left:     a.format(i),
+				LeftLine: a.lines[i],
+				Path:     path,
+			})
+			continue
+		}
+
+		j := pending[id][0]
+		pending[id] = pending[id][1:]
+
+		for _, name := range columns {
+			left := a.cell(i, name)
+			right := b.cell(j, name)
+
+			if left == right {
+				continue
+			}
+
+			pair := SimilarDiffPair{
+				Group:     Changed,
+				Left:      left,
+				Right:     right,
+				LeftLine:  a.lines[i],
+				RightLine: b.lines[j],
+				Path:      path + "." + name,
+			}
+
+			similar, rules := similarLines(left, right, append(append([]SimilarDiffChange{}, s.Changes...), opts.Columns[name]...), "")
+
+			/* cells are similar */
+			if similar {
+				s.Discarded = append(s.Discarded, SimilarDiffDiscard{
+					Pair:     pair,
+					Rules:    rules,
+					Position: len(s.Pairs),
+				})
+				continue
+			}
+
+			s.Pairs = append(s.Pairs, pair)
+		}
+	}
+
+	for j := range b.rows {
+		id := b.rowKey(j, keys)
+
+		if len(pending[id]) == 0 || pending[id][0] != j {
+			continue
+		}
+
+		pending[id] = pending[id][1:]
+
+		s.Pairs = append(s.Pairs, SimilarDiffPair{
+			Group:     Added,
+			Right:     b.format(j),
+			RightLine: b.lines[j],
+			Path:      rowPath(b, j, keys),
+		})
+	}
+
+	return Result{
+		FileA:     fileA,
+		FileB:     fileB,
+		Pairs:     s.Pairs,
+		Discarded: s.Discarded,
+	}, nil
+}
+
+// readTable parses a CSV file, or a TSV file if the extension says so; the
+// first record is the header.
+func readTable(filename string) (*table, error) {
+	file, err := os.Open(filename)
+
+	if err != nil {
+		return nil, &ParseError{Name: filename, Err: err}
+	}
+
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	if strings.ToLower(filepath.Ext(filename)) == ".tsv" {
+		reader.Comma = '\t'
+	}
+
+	t := &table{index: map[string]int{}}
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, &ParseError{Name: filename, Err: err}
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if t.header == nil {
+			t.header = record
+
+			for i, name := range record {
+				t.index[name] = i
+			}
+
+			continue
+		}
+
+		t.rows = append(t.rows, record)
+		t.lines = append(t.lines, line)
+	}
+
+	return t, nil
+}
+
+// cell returns the value of a column in a row, or an empty string if the
+// row does not have such column.
+func (t *table) cell(row int, name string) string {
+	col, ok := t.index[name]
+
+	if !ok || col >= len(t.rows[row]) {
+		return ""
+	}
+
+	return t.rows[row][col]
+}
+
+func (t *table) rowKey(row int, keys []string) string {
+	values := make([]string, len(keys))
+
+	for i, key := range keys {
+		values[i] = t.cell(row, key)
+	}
+
+	return strings.Join(values, "\x00")
+}
+
+// format returns the row as a list of column=value.
+func (t *table) format(row int) string {
+	values := make([]string, 0, len(t.header))
+
+	for _, name := range t.header {
+		values = append(values, name+"="+t.cell(row, name))
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func rowPath(t *table, row int, keys []string) string {
+	values := make([]string, len(keys))
+
+	for i, key := range keys {
+		values[i] = key + "=" + t.cell(row, key)
+	}
+
+	return "row[" + strings.Join(values, ",") + "]"
+}
+
+func contains(list []string, item string) bool {
+	for _, value := range list {
+		if value == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,48 @@
+package similardiff
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareTables(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.csv": "" +
+			"id,name,env,price,updated\n" +
+			"1,foo,prod-1,10,monday\n" +
+			"2,bar,prod-2,20,monday\n" +
+			"3,\"baz, qux\",prod-3,30,monday\n",
+		"b.csv": "" +
+			"id,name,env,price,updated\n" +
+			"4,new,stage-4,40,tuesday\n" +
+			"2,bar,stage-2,25,tuesday\n" +
+			"1,foo,stage-1,10,tuesday\n",
+	})
+
+	opts := Options{
+		Tabular: true,
+		Keys:    []string{"id"},
+		Ignore:  []string{"updated"},
+		Columns: map[string][]SimilarDiffChange{"env": {{Old: "prod", New: "stage"}}},
+	}
+
+	result, err := CompareFiles(filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv"), opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "20", Right: "25", LeftLine: 3, RightLine: 3, Path: "row[id=2].price"},
+		{Group: 'd', Left: "id=3, name=baz, qux, env=prod-3, price=30, updated=monday", LeftLine: 4, Path: "row[id=3]"},
+		{Group: 'a', Right: "id=4, name=new, env=stage-4, price=40, updated=tuesday", RightLine: 2, Path: "row[id=4]"},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 3, expected)
+
+	if len(result.Discarded) != 2 {
+		t.Fatalf("expecting the env column to be discarded twice; got %#v", result.Discarded)
+	}
+}
@@ -0,0 +1,211 @@
+package similardiff
+
+import (
+	"sort"
+)
+
+// Ours marks a change that was only made in file A.
+const Ours rune = 'o'
+
+// Theirs marks a change that was only made in file B.
+const Theirs rune = 't'
+
+// Both marks a change that was made in both files in the same way, once the
+// similarity rules are applied.
+const Both rune = 'b'
+
+// Conflict marks a change that was made in both files in different ways.
+const Conflict rune = 'x'
+
+// ThreeWayChange is a change made to one line of the common ancestor, or a
+// group of lines inserted after it, by one or both of the files.
+//
+// For insertions, BaseLine is the line after which the lines were inserted,
+// and Base is empty. Ours and Theirs hold the pairs produced by comparing the
+// ancestor with file A and file B, respectively.
+type ThreeWayChange struct {
+	Class    rune
+	Insert   bool
+	BaseLine int
+	Base     string
+	Ours     []SimilarDiffPair
+	Theirs   []SimilarDiffPair
+}
+
+// ThreeWayResult holds the classified changes of a three-way comparison.
+type ThreeWayResult struct {
+	Base    string
+	FileA   string
+	FileB   string
+	Changes []ThreeWayChange
+}
+
+// threeWaySide indexes the pairs of one side by position in the ancestor.
+type threeWaySide struct {
+	edits   map[int]SimilarDiffPair
+	inserts map[int][]SimilarDiffPair
+}
+
+// CompareThreeWay compares two files against their common ancestor and
+// classifies every change as ours, theirs, both or conflicting. Changes that
+// the similarity rules explain are not considered changes at all, and the
+// rules are also used to decide if both files made the same change.
+//
+// JSON, YAML, CSV and TSV files are compared line by line, and binary files
+// cannot be compared; ErrNotLineBased is returned instead.
+func CompareThreeWay(base string, fileA string, fileB string, opts Options) (ThreeWayResult, error) {
+	result := ThreeWayResult{Base: base, FileA: fileA, FileB: fileB}
+
+	/* changes are anchored to single lines of the base */
+	opts.Moves = false
+	opts.Structural = false
+	opts.Tabular = false
+
+	ours, err := CompareFiles(base, fileA, opts)
+
+	if err != nil {
+		return result, err
+	}
+
+	theirs, err := CompareFiles(base, fileB, opts)
+
+	if err != nil {
+		return result, err
+	}
+
+	if !ours.LineBased() || !theirs.LineBased() {
+		return result, ErrNotLineBased
+	}
+
+	result.Changes = classifyThreeWay(newThreeWaySide(ours.Pairs), newThreeWaySide(theirs.Pairs), opts.Changes)
+
+	return result, nil
+}
+
+func newThreeWaySide(pairs []SimilarDiffPair) threeWaySide {
+	side := threeWaySide{
+		edits:   map[int]SimilarDiffPair{},
+		inserts: map[int][]SimilarDiffPair{},
+	}
+
+	/* number of added minus deleted lines seen so far */
+	var delta int
+
+	for _, pair := range pairs {
+		switch pair.Group {
+		case Changed:
+			side.edits[pair.LeftLine] = pair
+		case Deleted:
+			side.edits[pair.LeftLine] = pair
+			delta--
+		case Added:
+			/* insertions are anchored to the preceding line in the ancestor */
+			anchor := pair.RightLine - delta - 1
+			side.inserts[anchor] = append(side.inserts[anchor], pair)
+			delta++
+		}
+	}
+
+	return side
+}
+
+func classifyThreeWay(ours threeWaySide, theirs threeWaySide, changes []SimilarDiffChange) []ThreeWayChange {
+	var out []ThreeWayChange
+
+	for _, line := range sortedKeys(ours.edits, theirs.edits) {
+		change := ThreeWayChange{BaseLine: line}
+
+		if pair, ok := ours.edits[line]; ok {
+			change.Base = pair.Left
+			change.Ours = []SimilarDiffPair{pair}
+		}
+
+		if pair, ok := theirs.edits[line]; ok {
+			change.Base = pair.Left
+			change.Theirs = []SimilarDiffPair{pair}
+		}
+
+		change.Class = classify(change, changes)
+		out = append(out, change)
+	}
+
+	for _, line := range sortedKeys(ours.inserts, theirs.inserts) {
+		change := ThreeWayChange{
+			Insert:   true,
+			BaseLine: line,
+			Ours:     ours.inserts[line],
+			Theirs:   theirs.inserts[line],
+		}
+
+		change.Class = classify(change, changes)
+		out = append(out, change)
+	}
+
+	/* insertions go after the edit of the line they are anchored to */
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].BaseLine == out[j].BaseLine {
+			return !out[i].Insert && out[j].Insert
+		}
+
+		return out[i].BaseLine < out[j].BaseLine
+	})
+
+	return out
+}
+
+func classify(change ThreeWayChange, changes []SimilarDiffChange) rune {
+	if len(change.Theirs) == 0 {
+		return Ours
+	}
+
+	if len(change.Ours) == 0 {
+		return Theirs
+	}
+
+	if sameChange(change.Ours, change.Theirs, changes) {
+		return Both
+	}
+
+	return Conflict
+}
+
+// sameChange reports whether both sides produced the same lines, once the
+// similarity rules are applied to the lines of file A.
+func sameChange(ours []SimilarDiffPair, theirs []SimilarDiffPair, changes []SimilarDiffChange) bool {
+	if len(ours) != len(theirs) {
+		return false
+	}
+
+	for i := range ours {
+		if ours[i].Group != theirs[i].Group {
+			return false
+		}
+
+		right, _ := applyChanges(ours[i].Right, changes)
+
+		if ours[i].Right != theirs[i].Right && right != theirs[i].Right {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortedKeys returns the union of the keys of both maps in ascending order.
+func sortedKeys[T any](a map[int]T, b map[int]T) []int {
+	var keys []int
+
+	for key := range a {
+		keys = append(keys, key)
+	}
+
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,95 @@
+package similardiff
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareThreeWay(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"base.txt": "import a\nline 2\nline 3\nline 4\nline 5\nline 6\n",
+		"a.txt":    "import a\nline 2 ours\nline 3\nimport x\nline 5 ours\nline 6\nnew ours\n",
+		"b.txt":    "include a\nline 2\nline 3 theirs\ninclude x\nline 5 theirs\nline 6\n",
+	})
+
+	opts := Options{Changes: []SimilarDiffChange{{Old: "import", New: "include"}}}
+
+	result, err := CompareThreeWay(
+		filepath.Join(dir, "base.txt"),
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "b.txt"),
+		opts,
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		class    rune
+		baseLine int
+		insert   bool
+	}{
+		{Ours, 2, false},
+		{Theirs, 3, false},
+		{Both, 4, false},
+		{Conflict, 5, false},
+		{Ours, 6, true},
+	}
+
+	if len(result.Changes) != len(expected) {
+		t.Fatalf("unexpected changes: %#v", result.Changes)
+	}
+
+	for i, change := range result.Changes {
+		if change.Class != expected[i].class || change.BaseLine != expected[i].baseLine || change.Insert != expected[i].insert {
+			t.Fatalf("unexpected change %d: %#v", i, change)
+		}
+	}
+}
+
+func TestCompareThreeWayStructured(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"base.json": "{\n  \"name\": \"app\",\n  \"port\": 80,\n  \"debug\": false\n}\n",
+		"a.json":    "{\n  \"name\": \"web\",\n  \"port\": 80,\n  \"debug\": false\n}\n",
+		"b.json":    "{\n  \"name\": \"app\",\n  \"port\": 80,\n  \"debug\": true\n}\n",
+		"base.bin":  "\x00\x01",
+		"a.bin":     "\x00\x02",
+		"b.bin":     "\x00\x03",
+	})
+
+	opts := Options{Structural: true, Tabular: true}
+
+	result, err := CompareThreeWay(
+		filepath.Join(dir, "base.json"),
+		filepath.Join(dir, "a.json"),
+		filepath.Join(dir, "b.json"),
+		opts,
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Changes) != 2 ||
+		result.Changes[0].Class != Ours || result.Changes[0].BaseLine != 2 ||
+		result.Changes[1].Class != Theirs || result.Changes[1].BaseLine != 4 {
+		t.Fatalf("unexpected changes: %#v", result.Changes)
+	}
+
+	_, err = CompareThreeWay(
+		filepath.Join(dir, "base.bin"),
+		filepath.Join(dir, "a.bin"),
+		filepath.Join(dir, "b.bin"),
+		opts,
+	)
+
+	if !errors.Is(err, ErrNotLineBased) {
+		t.Fatalf("expecting ErrNotLineBased; got %v", err)
+	}
+}
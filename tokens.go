@@ -0,0 +1,295 @@
+package similardiff
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of similarity rules. A rule without a kind replaces every occurrence
+// of its text, even inside other words. Word rules only replace whole words.
+// Identifier, keyword and string rules tokenize the line according to the
+// language of file A and only modify tokens of that kind; string rules
+// replace text inside string literals, which only exist in the languages
+// returned by LanguageOf.
+const (
+	KindWord       = "word"
+	KindIdentifier = "identifier"
+	KindKeyword    = "keyword"
+	KindString     = "string"
+)
+
+// Kinds of tokens produced by tokenizeCode.
+const (
+	tokenSpace      = "space"
+	tokenComment    = "comment"
+	tokenString     = "string"
+	tokenNumber     = "number"
+	tokenIdentifier = KindIdentifier
+	tokenKeyword    = KindKeyword
+	tokenSymbol     = "symbol"
+)
+
+// language describes the lexical rules of a programming language.
+type language struct {
+	lineComment  string
+	blockComment bool
+	quotes       string
+	rawQuote     rune
+	keywords     map[string]bool
+}
+
+// languages maps the names returned by LanguageOf to their lexical rules.
+// Lines of files in other languages are tokenized with generic rules, where
+// every word is an identifier.
+var languages = map[string]*language{
+	"go": {
+		lineComment:  "//",
+		blockComment: true,
+		quotes:       "\"'`",
+		rawQuote:     '`',
+		keywords: keywordSet("break case chan const continue default defer else fallthrough for func " +
+			"go goto if import interface map package range return select struct switch type var"),
+	},
+	"c": {
+		lineComment:  "//",
+		blockComment: true,
+		quotes:       "\"'",
+		keywords: keywordSet("auto break case char const continue default do double else enum extern " +
+			"float for goto if inline int long register restrict return short signed sizeof static " +
+			"struct switch typedef union unsigned void volatile while"),
+	},
+	"python": {
+		lineComment: "#",
+		quotes:      "\"'",
+		keywords: keywordSet("False None True and as assert async await break class continue def del " +
+			"elif else except finally for from global if import in is lambda nonlocal not or pass " +
+			"raise return try while with yield"),
+	},
+	"javascript": {
+		lineComment:  "//",
+		blockComment: true,
+		quotes:       "\"'`",
+		keywords: keywordSet("async await break case catch class const continue debugger default delete " +
+			"do else export extends false finally for function if import in instanceof let new null " +
+			"return super switch this throw true try typeof var void while with yield"),
+	},
+}
+
+var languageExtensions = map[string]string{
+	".go":  "go",
+	".c":   "c",
+	".h":   "c",
+	".py":  "python",
+	".js":  "javascript",
+	".mjs": "javascript",
+	".cjs": "javascript",
+	".jsx": "javascript",
+	".ts":  "javascript",
+	".tsx": "javascript",
+}
+
+// genericLanguage tokenizes the lines of other files. Quotes are not
+// strings because, in prose, an apostrophe would start one.
+var genericLanguage = &language{}
+
+// LanguageOf returns the programming language of a file according to its
+// extension: "go", "c", "python" or "javascript". Other files yield an
+// empty string.
+func LanguageOf(name string) string {
+	return languageExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+func keywordSet(list string) map[string]bool {
+	set := map[string]bool{}
+
+	for _, word := range strings.Fields(list) {
+		set[word] = true
+	}
+
+	return set
+}
+
+type token struct {
+	kind string
+	text string
+}
+
+// tokenizeCode splits a line of code into tokens. Lines are tokenized one at
+// a time, so strings and comments that span several lines are not detected.
+func tokenizeCode(line string, name string) []token {
+	var tokens []token
+
+	lang, ok := languages[name]
+
+	if !ok {
+		lang = genericLanguage
+	}
+
+	/* offsets are in bytes; the rest of the line is a substring, not a copy */
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		j := i + size
+		kind := tokenSymbol
+		rest := line[i:]
+
+		switch {
+		case unicode.IsSpace(r):
+			kind = tokenSpace
+			j = skipRunes(line, j, unicode.IsSpace)
+		case lang.lineComment != "" && strings.HasPrefix(rest, lang.lineComment):
+			kind, j = tokenComment, len(line)
+		case lang.blockComment && strings.HasPrefix(rest, "/*"):
+			kind, j = tokenComment, len(line)
+			if end := strings.Index(rest[2:], "*/"); end >= 0 {
+				j = i + 2 + end + 2
+			}
+		case strings.ContainsRune(lang.quotes, r):
+			kind = tokenString
+			for j < len(line) {
+				c, n := utf8.DecodeRuneInString(line[j:])
+				if c == r {
+					j += n /* closing quote */
+					break
+				}
+				j += n
+				if c == '\\' && r != lang.rawQuote && j < len(line) {
+					_, n = utf8.DecodeRuneInString(line[j:])
+					j += n
+				}
+			}
+		case unicode.IsDigit(r):
+			kind = tokenNumber
+			j = skipRunes(line, j, func(c rune) bool { return isWord(c) || c == '.' })
+		case isWord(r):
+			kind = tokenIdentifier
+			j = skipRunes(line, j, isWord)
+			if lang.keywords[line[i:j]] {
+				kind = tokenKeyword
+			}
+		}
+
+		tokens = append(tokens, token{kind: kind, text: line[i:j]})
+		i = j
+	}
+
+	return tokens
+}
+
+// skipRunes returns the offset of the first rune of the line, starting at
+// offset j, that does not satisfy the function.
+func skipRunes(line string, j int, f func(rune) bool) int {
+	for j < len(line) {
+		r, size := utf8.DecodeRuneInString(line[j:])
+
+		if !f(r) {
+			break
+		}
+
+		j += size
+	}
+
+	return j
+}
+
+// applyRule applies one similarity to the line, and reports whether the rule
+// modified it. The language decides how identifier, keyword and string rules
+// tokenize the line.
+func applyRule(line string, change SimilarDiffChange, lang string) (string, bool) {
+	switch change.Kind {
+	case "":
+		if !strings.Contains(line, change.Old) {
+			return line, false
+		}
+		return strings.Replace(line, change.Old, change.New, -1), true
+	case KindWord:
+		return replaceWord(line, change.Old, change.New)
+	case KindPattern, KindTolerance:
+		return line, false /* both lines are needed, see similarLines */
+	}
+
+	var modified bool
+	var out strings.Builder
+
+	_, known := languages[lang]
+
+	for _, tok := range tokenizeCode(line, lang) {
+		switch {
+		case change.Kind == KindString && tok.kind == tokenString:
+			if strings.Contains(tok.text, change.Old) {
+				tok.text = strings.Replace(tok.text, change.Old, change.New, -1)
+				modified = true
+			}
+		case tok.text != change.Old:
+		case tok.kind == change.Kind,
+			/* without a language, keywords cannot be told apart */
+			!known && change.Kind == KindKeyword && tok.kind == tokenIdentifier:
+			tok.text = change.New
+			modified = true
+		}
+
+		out.WriteString(tok.text)
+	}
+
+	return out.String(), modified
+}
+
+// replaceWord replaces the occurrences of old that are not part of a longer
+// word, that is, when the characters around them cannot extend the word.
+func replaceWord(line string, old string, new string) (string, bool) {
+	var modified bool
+	var out strings.Builder
+
+	if old == "" {
+		return line, false
+	}
+
+	/* end of the text already written; boundaries look at the whole line */
+	var last int
+
+	for from := 0; ; {
+		n := strings.Index(line[from:], old)
+
+		if n < 0 {
+			break
+		}
+
+		n += from
+		end := n + len(old)
+
+		if wordBoundary(line[:n], old, true) && wordBoundary(line[end:], old, false) {
+			out.WriteString(line[last:n])
+			out.WriteString(new)
+			modified = true
+			last = end
+		}
+
+		from = end
+	}
+
+	out.WriteString(line[last:])
+
+	return out.String(), modified
+}
+
+// wordBoundary reports whether the text next to an occurrence of the word
+// does not continue it. Only the edges of the word made of word characters
+// need a boundary.
+func wordBoundary(text string, word string, before bool) bool {
+	var edge, next rune
+
+	if before {
+		edge, _ = utf8.DecodeRuneInString(word)
+		if text != "" {
+			next, _ = utf8.DecodeLastRuneInString(text)
+		}
+	} else {
+		edge, _ = utf8.DecodeLastRuneInString(word)
+		if text != "" {
+			next, _ = utf8.DecodeRuneInString(text)
+		}
+	}
+
+	return !isWord(edge) || next == 0 || !isWord(next)
+}
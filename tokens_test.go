@@ -0,0 +1,124 @@
+package similardiff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyRuleKinds(t *testing.T) {
+	tests := []struct {
+		line     string
+		change   SimilarDiffChange
+		lang     string
+		expected string
+	}{
+		{"package subpackage packages", SimilarDiffChange{Old: "package", New: "module"}, "", "module submodule modules"},
+		{"package subpackage packages", SimilarDiffChange{Old: "package", New: "module", Kind: KindWord}, "", "module subpackage packages"},
+		{"a->next = next_b;", SimilarDiffChange{Old: "next", New: "Next", Kind: KindWord}, "c", "a->Next = next_b;"},
+		{"i++ i+++", SimilarDiffChange{Old: "i++", New: "j", Kind: KindWord}, "", "j j+"},
+		{"packagepackage package", SimilarDiffChange{Old: "package", New: "module", Kind: KindWord}, "", "packagepackage module"},
+		{"it's foo", SimilarDiffChange{Old: "foo", New: "bar", Kind: KindIdentifier}, "", "it's bar"},
+		{`say "foo"`, SimilarDiffChange{Old: "foo", New: "bar", Kind: KindString}, "", `say "foo"`},
+		{`len := len("len") // len`, SimilarDiffChange{Old: "len", New: "size", Kind: KindIdentifier}, "go", `size := size("len") // len`},
+		{"for x in range(n): pass", SimilarDiffChange{Old: "pass", New: "continue", Kind: KindKeyword}, "python", "for x in range(n): continue"},
+		{"var pass = 1", SimilarDiffChange{Old: "pass", New: "skip", Kind: KindKeyword}, "javascript", "var pass = 1"},
+		{`open("a.txt", 'a.txt') # a.txt`, SimilarDiffChange{Old: "a.txt", New: "b.txt", Kind: KindString}, "python", `open("b.txt", 'b.txt') # a.txt`},
+		{`s = "it\"s a"; /* a */ a`, SimilarDiffChange{Old: "a", New: "b", Kind: KindString}, "c", `s = "it\"s b"; /* a */ a`},
+	}
+
+	for _, test := range tests {
+		line, _ := applyRule(test.line, test.change, test.lang)
+
+		if line != test.expected {
+			t.Fatalf("%q with %#v\nexpecting: %q\ngot:       %q", test.line, test.change, test.expected, line)
+		}
+	}
+}
+
+func TestCompareTokenRules(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.go":            "package main\nimport \"subpackage\"\nvar packages = 1\n",
+		"b.go":            "module main\nimport \"subpackage\"\nvar modules = 1\n",
+		"similardiff.ini": "[keyword]\npackage=module\n",
+	})
+
+	config, err := LoadConfig(filepath.Join(dir, "similardiff.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := CompareFiles(filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go"), Options{Changes: config.Changes})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "var packages = 1", Right: "var modules = 1", LeftLine: 3, RightLine: 3},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	if len(result.Discarded) != 1 || result.Discarded[0].Rules[0].Kind != KindKeyword {
+		t.Fatalf("unexpected discarded pairs: %#v", result.Discarded)
+	}
+
+	/* rules with a kind are written in a section of their kind */
+	if err := AppendChange(filepath.Join(dir, "similardiff.ini"), SimilarDiffChange{Old: "packages", New: "modules", Kind: KindIdentifier}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "similardiff.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "[keyword]\npackage=module\n[identifier]\npackages=modules\n" {
+		t.Fatalf("unexpected configuration:\n%s", data)
+	}
+}
+
+func TestTokenizeCode(t *testing.T) {
+	tokens := tokenizeCode(`añ := "ü\"é" /* ö */ x // "z`, "go")
+
+	expected := []token{
+		{tokenIdentifier, "añ"},
+		{tokenSpace, " "},
+		{tokenSymbol, ":"},
+		{tokenSymbol, "="},
+		{tokenSpace, " "},
+		{tokenString, `"ü\"é"`},
+		{tokenSpace, " "},
+		{tokenComment, "/* ö */"},
+		{tokenSpace, " "},
+		{tokenIdentifier, "x"},
+		{tokenSpace, " "},
+		{tokenComment, `// "z`},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("unexpected tokens: %#v", tokens)
+	}
+
+	for i := range tokens {
+		if tokens[i] != expected[i] {
+			t.Fatalf("unexpected token %d: %#v", i, tokens[i])
+		}
+	}
+
+	/* unterminated strings end with the line, even after a backslash */
+	if tokens := tokenizeCode(`x = "abc\`, "c"); tokens[len(tokens)-1] != (token{tokenString, `"abc\`}) {
+		t.Fatalf("unexpected tokens: %#v", tokens)
+	}
+
+	/* long minified lines are tokenized in linear time */
+	if tokens := tokenizeCode(strings.Repeat("a=1;", 100000), "javascript"); len(tokens) != 400000 {
+		t.Fatalf("unexpected number of tokens: %d", len(tokens))
+	}
+}
@@ -0,0 +1,179 @@
+package similardiff
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KindTolerance marks a rule that compares the numbers of both lines. Old is
+// either "absolute" or "relative" and New is the tolerance, for example
+// "absolute=0.001". Two lines are similar if the text around the numbers is
+// the same and every pair of aligned numbers is within any of the
+// tolerances; the relative tolerance is a fraction of the largest number.
+const KindTolerance = "tolerance"
+
+var number = regexp.MustCompile(`[-+]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:[eE][-+]?[0-9]+)?`)
+
+// parseTolerance validates a tolerance rule.
+func parseTolerance(change SimilarDiffChange) (SimilarDiffChange, error) {
+	change.Old = strings.TrimSpace(change.Old)
+	change.New = strings.TrimSpace(change.New)
+
+	if change.Old != "absolute" && change.Old != "relative" {
+		return change, fmt.Errorf("unknown tolerance %s", change.Old)
+	}
+
+	value, err := strconv.ParseFloat(change.New, 64)
+
+	if err != nil || value < 0 || math.IsNaN(value) {
+		return change, fmt.Errorf("invalid tolerance %s", change.New)
+	}
+
+	change.Kind = KindTolerance
+
+	return change, nil
+}
+
+// rulesFor returns the similarity rules used to compare a file: the global
+// ones, followed by the tolerances of the patterns that match the name of
+// the file or its path, see matchesPath.
+func (opts Options) rulesFor(name string) []SimilarDiffChange {
+	if len(opts.Tolerances) == 0 {
+		return opts.Changes
+	}
+
+	rules := append([]SimilarDiffChange{}, opts.Changes...)
+
+	keys := make([]string, 0, len(opts.Tolerances))
+
+	for pattern := range opts.Tolerances {
+		keys = append(keys, pattern)
+	}
+
+	sort.Strings(keys)
+
+	for _, pattern := range keys {
+		if pattern == "*" || matchesPath(name, pattern) {
+			rules = append(rules, opts.Tolerances[pattern]...)
+		}
+	}
+
+	return rules
+}
+
+// matchesPath reports whether the pattern matches the file, or one of its
+// directories, wherever the path of the file starts. The name can be
+// relative to any directory or absolute, so "bench/*" matches
+// "./bench/a.txt", "/src/bench/a.txt" and "../bench/x/a.txt".
+func matchesPath(name string, pattern string) bool {
+	suffix := path.Clean(filepath.ToSlash(name))
+
+	for {
+		if matchesAny(suffix, []string{pattern}) {
+			return true
+		}
+
+		i := strings.Index(suffix, "/")
+
+		if i < 0 {
+			return false
+		}
+
+		suffix = suffix[i+1:]
+	}
+}
+
+// hasTolerances reports whether any of the rules is a tolerance.
+func hasTolerances(changes []SimilarDiffChange) bool {
+	for _, change := range changes {
+		if change.Kind == KindTolerance {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withinTolerance reports whether both lines only differ in numbers that are
+// within the tolerances, and returns the tolerances that were needed. Every
+// number written differently needs a tolerance that accepts it, even if both
+// have the same value, so "1.0" and "1.00" are only similar with a rule.
+func withinTolerance(left string, right string, changes []SimilarDiffChange) (bool, []SimilarDiffChange) {
+	var used []SimilarDiffChange
+
+	a := number.FindAllStringIndex(left, -1)
+	b := number.FindAllStringIndex(right, -1)
+
+	if len(a) == 0 || len(a) != len(b) {
+		return false, nil
+	}
+
+	var lastA, lastB int
+
+	for i := range a {
+		/* the text between the numbers must match */
+		if left[lastA:a[i][0]] != right[lastB:b[i][0]] {
+			return false, nil
+		}
+
+		lastA, lastB = a[i][1], b[i][1]
+
+		if left[a[i][0]:lastA] == right[b[i][0]:lastB] {
+			continue
+		}
+
+		x, errX := strconv.ParseFloat(left[a[i][0]:lastA], 64)
+		y, errY := strconv.ParseFloat(right[b[i][0]:lastB], 64)
+
+		if errX != nil || errY != nil {
+			return false, nil
+		}
+
+		rule, ok := tolerates(x, y, changes)
+
+		if !ok {
+			return false, nil
+		}
+
+		used = appendRules(used, []SimilarDiffChange{rule})
+	}
+
+	if left[lastA:] != right[lastB:] {
+		return false, nil
+	}
+
+	return true, used
+}
+
+// tolerates returns the first tolerance rule that accepts the difference.
+func tolerates(x float64, y float64, changes []SimilarDiffChange) (SimilarDiffChange, bool) {
+	diff := math.Abs(x - y)
+
+	for _, change := range changes {
+		if change.Kind != KindTolerance {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(change.New, 64)
+
+		if err != nil {
+			continue
+		}
+
+		if change.Old == "relative" {
+			value *= math.Max(math.Abs(x), math.Abs(y))
+		}
+
+		if diff <= value {
+			return change, true
+		}
+	}
+
+	return SimilarDiffChange{}, false
+}
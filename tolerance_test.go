@@ -0,0 +1,135 @@
+package similardiff
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithinTolerance(t *testing.T) {
+	absolute := SimilarDiffChange{Old: "absolute", New: "0.01", Kind: KindTolerance}
+	relative := SimilarDiffChange{Old: "relative", New: "0.001", Kind: KindTolerance}
+	rules := []SimilarDiffChange{absolute, relative}
+
+	tests := []struct {
+		left    string
+		right   string
+		similar bool
+		used    int
+	}{
+		{"time: 1.004 s", "time: 1.0 s", true, 1},
+		{"time: 1.02 s", "time: 1.0 s", false, 0},
+		{"total 10000 items", "total 10009 items", true, 1},
+		{"x=1.5e3, y=-2", "x=1.501e3, y=-2.005", true, 2},
+		{"time: 1.004 s", "time: 1.004 ms", false, 0},
+		{"1 2", "1", false, 0},
+		{"no numbers", "no numbers!", false, 0},
+	}
+
+	for _, test := range tests {
+		similar, used := withinTolerance(test.left, test.right, rules)
+
+		if similar != test.similar || len(used) != test.used {
+			t.Fatalf("%q and %q: expecting %v with %d rules; got %v with %#v", test.left, test.right, test.similar, test.used, similar, used)
+		}
+	}
+}
+
+func TestCompareTolerances(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt":           "speed 3.14159\nlabel 1\n",
+		"b.txt":           "speed 3.1416\nlabel 2\n",
+		"a.dat":           "speed 3.14159\n",
+		"b.dat":           "speed 3.1416\n",
+		"similardiff.ini": "[tolerance *.txt]\nabsolute = 0.0001\n",
+		"broken.ini":      "[tolerance]\nabsolute=-1\n",
+	})
+
+	config, err := LoadConfig(filepath.Join(dir, "similardiff.ini"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Tolerances: config.Tolerances}
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "label 1", Right: "label 2", LeftLine: 2, RightLine: 2},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 1, expected)
+
+	if len(result.Discarded) != 1 || result.Discarded[0].Rules[0].String() != "absolute=0.0001" {
+		t.Fatalf("unexpected discarded pairs: %#v", result.Discarded)
+	}
+
+	/* the tolerance does not apply to other files */
+	if result, err = CompareFiles(filepath.Join(dir, "a.dat"), filepath.Join(dir, "b.dat"), opts); err != nil || len(result.Pairs) != 1 {
+		t.Fatalf("unexpected result: %#v, %v", result, err)
+	}
+
+	var configErr *ConfigError
+
+	if _, err := LoadConfig(filepath.Join(dir, "broken.ini")); !errors.As(err, &configErr) || configErr.Line != 2 {
+		t.Fatalf("expecting ConfigError on line 2; got %#v", err)
+	}
+}
+
+func TestCompareWithoutTolerances(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "value 1.0\ncode 007\nlimit 1e3\n",
+		"b.txt": "value 1.00\ncode 7\nlimit 1000\n",
+	})
+
+	result, err := CompareFiles(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SimilarDiffPair{
+		{Group: 'c', Left: "value 1.0", Right: "value 1.00", LeftLine: 1, RightLine: 1},
+		{Group: 'c', Left: "code 007", Right: "code 7", LeftLine: 2, RightLine: 2},
+		{Group: 'c', Left: "limit 1e3", Right: "limit 1000", LeftLine: 3, RightLine: 3},
+	}
+
+	CheckTestData(t, &SimilarDiff{Pairs: result.Pairs}, 3, expected)
+
+	/* numbers written differently need a tolerance even if they are equal */
+	if similar, _ := withinTolerance("value 1.0", "value 1.00", nil); similar {
+		t.Fatal("expecting different lines without tolerance rules")
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	tolerance := SimilarDiffChange{Old: "absolute", New: "0.5", Kind: KindTolerance}
+	opts := Options{Tolerances: map[string][]SimilarDiffChange{"bench/*": {tolerance}}}
+
+	abs, err := filepath.Abs(filepath.Join("bench", "a.txt"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"bench/a.txt", "./bench/a.txt", "../bench/a.txt", "bench/x/a.txt", "/tmp/project/bench/a.txt", abs} {
+		if rules := opts.rulesFor(name); len(rules) != 1 {
+			t.Fatalf("expecting the tolerance for %s; got %#v", name, rules)
+		}
+	}
+
+	for _, name := range []string{"a.txt", "benchmarks/a.txt", "src/a.txt"} {
+		if rules := opts.rulesFor(name); len(rules) != 0 {
+			t.Fatalf("unexpected rules for %s: %#v", name, rules)
+		}
+	}
+}